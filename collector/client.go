@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/config"
+)
+
+// ClientConfig configures the client requesting the logstash API.
+type ClientConfig struct {
+	// HTTPClientConfig holds the TLS, basic auth and bearer token settings.
+	config.HTTPClientConfig
+
+	// APIKey, or the content of APIKeyFile read on every request, is sent as "Authorization: ApiKey <key>".
+	APIKey     config.Secret
+	APIKeyFile string
+
+	// Headers are added to every request, e.g. for an authenticating proxy in front of logstash.
+	Headers map[string]config.Secret
+	// HeaderFiles are added to every request like Headers, with the content of the files read on every request.
+	HeaderFiles map[string]string
+
+	Timeout time.Duration
+}
+
+// NewHTTPClient returns a client for the logstash API.
+// The client is safe to share between collectors.
+func NewHTTPClient(cfg ClientConfig) (*http.Client, error) {
+	tlsConfig, err := config.NewTLSConfig(&cfg.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike config.NewClientFromConfig, the default transport doesn't register
+	// go-conntrack dialer metrics and keeps honoring the proxy environment variables.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	var rt http.RoundTripper = transport
+	if len(cfg.BearerToken) > 0 {
+		rt = config.NewBearerAuthRoundTripper(cfg.BearerToken, rt)
+	} else if len(cfg.BearerTokenFile) > 0 {
+		rt = config.NewBearerAuthFileRoundTripper(cfg.BearerTokenFile, rt)
+	}
+	if cfg.BasicAuth != nil {
+		rt = config.NewBasicAuthRoundTripper(cfg.BasicAuth.Username, cfg.BasicAuth.Password, cfg.BasicAuth.PasswordFile, rt)
+	}
+	if len(cfg.APIKey) > 0 || len(cfg.APIKeyFile) > 0 {
+		rt = &apiKeyRoundTripper{apiKey: cfg.APIKey, apiKeyFile: cfg.APIKeyFile, rt: rt}
+	}
+	if len(cfg.Headers) > 0 || len(cfg.HeaderFiles) > 0 {
+		rt = &headersRoundTripper{headers: cfg.Headers, headerFiles: cfg.HeaderFiles, rt: rt}
+	}
+	return &http.Client{Transport: rt, Timeout: cfg.Timeout}, nil
+}
+
+type apiKeyRoundTripper struct {
+	apiKey     config.Secret
+	apiKeyFile string
+	rt         http.RoundTripper
+}
+
+func (rt *apiKeyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(req.Header.Get("Authorization")) != 0 {
+		return rt.rt.RoundTrip(req)
+	}
+	apiKey := string(rt.apiKey)
+	if rt.apiKeyFile != "" {
+		b, err := ioutil.ReadFile(rt.apiKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read API key file %s: %w", rt.apiKeyFile, err)
+		}
+		apiKey = string(b)
+	}
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "ApiKey "+strings.TrimSpace(apiKey))
+	return rt.rt.RoundTrip(req)
+}
+
+type headersRoundTripper struct {
+	headers     map[string]config.Secret
+	headerFiles map[string]string
+	rt          http.RoundTripper
+}
+
+func (rt *headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	for name, value := range rt.headers {
+		req.Header.Set(name, string(value))
+	}
+	for name, file := range rt.headerFiles {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read header file %s: %w", file, err)
+		}
+		req.Header.Set(name, strings.TrimSpace(string(b)))
+	}
+	return rt.rt.RoundTrip(req)
+}
+
+// cloneRequest returns a shallow copy of the request with its own headers,
+// as a RoundTripper must not modify the given request.
+func cloneRequest(r *http.Request) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = r.Header.Clone()
+	return r2
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/common/config"
+)
+
+func TestHeaderFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(ClientConfig{
+		Headers:     map[string]config.Secret{"X-User": "exporter"},
+		HeaderFiles: map[string]string{"X-Token": tokenFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func() {
+		t.Helper()
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	get()
+	if got.Get("X-User") != "exporter" || got.Get("X-Token") != "first" {
+		t.Errorf("headers = %v", got)
+	}
+	// The file is read again on every request, so a rotated secret is picked up.
+	if err := ioutil.WriteFile(tokenFile, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	get()
+	if got.Get("X-Token") != "second" {
+		t.Errorf("X-Token = %q after rotation, want %q", got.Get("X-Token"), "second")
+	}
+
+	if err := os.Remove(tokenFile); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("request succeeded without the header file")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -16,64 +17,247 @@ import (
 )
 
 const (
-	namespace = "logstash"
-	statsPath = "/_node/stats"
+	namespace        = "logstash"
+	nodePath         = "/_node"
+	statsPath        = "/_node/stats"
+	healthReportPath = "/_health_report"
+	pipelinesPath    = "/_node/pipelines"
+	pluginsPath      = "/_node/plugins"
+	hotThreadsPath   = "/_node/hot_threads?human=false"
 )
 
 var (
 	ErrBadStatus = errors.New("bad status code")
 )
 
+// SubCollector describes a sub-collector which can be enabled or disabled.
+type SubCollector struct {
+	Name    string
+	Help    string
+	Default bool
+}
+
+// SubCollectors lists the sub-collectors which can be enabled or disabled.
+var SubCollectors = []SubCollector{
+	{Name: "jvm", Help: "JVM memory, threads and garbage collection from /_node/stats.", Default: true},
+	{Name: "process", Help: "Process CPU, memory and file descriptors from /_node/stats.", Default: true},
+	{Name: "pipeline_config", Help: "Default pipeline settings from /_node/stats.", Default: true},
+	{Name: "reloads_config", Help: "Config reload successes and failures from /_node/stats.", Default: true},
+	{Name: "event", Help: "Event totals of the node from /_node/stats.", Default: true},
+	{Name: "pipelines", Help: "Per-pipeline and per-plugin metrics from /_node/stats.", Default: true},
+	{Name: "os", Help: "Cgroup metrics from /_node/stats.", Default: true},
+	{Name: "flow", Help: "Flow metrics of the node from /_node/stats (logstash 8.5+).", Default: true},
+	{Name: "node_info", Help: "OS, JVM and pipeline defaults of the node from /_node, fetched again only when logstash restarts.", Default: true},
+	{Name: "health_report", Help: "Health report from /_health_report (logstash 8.16+).", Default: false},
+	{Name: "pipeline_settings", Help: "Per-pipeline settings from /_node/pipelines.", Default: false},
+	{Name: "pipeline_graph", Help: "Pipeline vertices and edges from /_node/pipelines?graph=true.", Default: false},
+	{Name: "plugins", Help: "Installed plugins and their versions from /_node/plugins.", Default: false},
+	{Name: "hot_threads", Help: "CPU usage of the busiest threads by pipeline from /_node/hot_threads, which is costly for logstash.", Default: false},
+}
+
+// LabelNames lists the label names of the exported metrics. Extra labels must not use them.
+var LabelNames = []string{
+	"arch", "batch_delay_seconds", "batch_size", "collector", "column", "condition", "control_group",
+	"ecs_compatibility", "ephemeral_id", "expected_version", "explicit_id", "from", "group", "host",
+	"http_address", "id", "index", "indicator", "line", "load", "message", "name", "ordered",
+	"parent_branch", "parent_conditional", "path", "pipeline", "plugin", "plugin_type", "pool",
+	"queue_type", "source", "state", "status", "storage_policy", "storage_type", "to", "type",
+	"version", "vm_name", "vm_vendor", "vm_version", "when", "window", "workers",
+}
+
 type Collector struct {
 	URI    string
 	mutex  sync.RWMutex
 	client *http.Client
+	opts   Options
 
 	up                prometheus.Gauge
 	totalScrapes      prometheus.Counter
 	jsonParseFailures prometheus.Counter
 	logstashStatus    prometheus.Gauge
 	logstashInfo      *prometheus.Desc
+	skippedPipelines  prometheus.Gauge
+	collectorSuccess  *prometheus.Desc
+
+	// cachedNodeInfo keeps /_node, which only changes when logstash restarts,
+	// so it is fetched again only when the ephemeral_id of the stats changes.
+	cachedNodeInfo      *NodeInfo
+	cachedNodeInfoOwner string
+
+	subCollectors
+}
 
-	jvm            *jvmCollector
-	process        *processCollector
-	pipelineConfig *pipelineConfigCollector
-	reloadsConfig  *reloadsConfigCollector
-	event          *eventCollector
-	pipeline       *pipelinesCollector
+// subCollectors holds the sub-collectors run by a scrape. The disabled ones are nil.
+type subCollectors struct {
+	jvm              *jvmCollector
+	process          *processCollector
+	pipelineConfig   *pipelineConfigCollector
+	reloadsConfig    *reloadsConfigCollector
+	event            *eventCollector
+	pipeline         *pipelinesCollector
+	os               *osCollector
+	flow             *flowCollector
+	nodeInfo         *nodeInfoCollector
+	healthReport     *healthReportCollector
+	pipelineSettings *pipelineSettingsCollector
+	pipelineGraph    *pipelineGraphCollector
+	plugins          *pluginsCollector
+	hotThreads       *hotThreadsCollector
 }
 
-func NewCollector(uri string, timeout time.Duration) (*Collector, error) {
+// only returns the sub-collectors among names, keyed like SubCollectors.
+func (s subCollectors) only(names map[string]bool) subCollectors {
+	if !names["jvm"] {
+		s.jvm = nil
+	}
+	if !names["process"] {
+		s.process = nil
+	}
+	if !names["pipeline_config"] {
+		s.pipelineConfig = nil
+	}
+	if !names["reloads_config"] {
+		s.reloadsConfig = nil
+	}
+	if !names["event"] {
+		s.event = nil
+	}
+	if !names["pipelines"] {
+		s.pipeline = nil
+	}
+	if !names["os"] {
+		s.os = nil
+	}
+	if !names["flow"] {
+		s.flow = nil
+	}
+	if !names["node_info"] {
+		s.nodeInfo = nil
+	}
+	if !names["health_report"] {
+		s.healthReport = nil
+	}
+	if !names["pipeline_settings"] {
+		s.pipelineSettings = nil
+	}
+	if !names["pipeline_graph"] {
+		s.pipelineGraph = nil
+	}
+	if !names["plugins"] {
+		s.plugins = nil
+	}
+	if !names["hot_threads"] {
+		s.hotThreads = nil
+	}
+	return s
+}
+
+// Options configures a Collector.
+type Options struct {
+	// Client requests the logstash API. A client with a 5s timeout is used if nil.
+	Client *http.Client
+	// Collectors enables or disables the sub-collectors by name. The others keep their default.
+	Collectors map[string]bool
+	// PluginManifest maps plugin names to their expected versions, checked by the plugins collector.
+	PluginManifest map[string]string
+	// PipelineInclude and PipelineExclude select the pipelines by id. All pipelines are selected if both are nil.
+	PipelineInclude *regexp.Regexp
+	PipelineExclude *regexp.Regexp
+	// PluginTypes, PluginInclude and PluginExclude select the plugins of the pipelines collector by type and by name or id.
+	// All plugins are selected if they are empty.
+	PluginTypes   []string
+	PluginInclude *regexp.Regexp
+	PluginExclude *regexp.Regexp
+	// Counters are the scrape counters of the collector. New ones are created if nil.
+	Counters *Counters
+}
+
+// Counters are the scrape counters of a Collector. The short-lived collectors of a target can share them,
+// so that they keep counting across scrapes.
+type Counters struct {
+	totalScrapes      prometheus.Counter
+	jsonParseFailures prometheus.Counter
+}
+
+func NewCounters() *Counters {
+	return &Counters{
+		totalScrapes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "exporter_total_scrapes",
+			Help:      "Current total logstash scrapes.",
+		}),
+		jsonParseFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "exporter_json_parse_failures",
+			Help:      "Number of errors while parsing JSON.",
+		}),
+	}
+}
+
+// pipelineSelected tells whether the metrics of the pipeline are exported.
+func (o Options) pipelineSelected(id string) bool {
+	if o.PipelineInclude != nil && !o.PipelineInclude.MatchString(id) {
+		return false
+	}
+	return o.PipelineExclude == nil || !o.PipelineExclude.MatchString(id)
+}
+
+func (o Options) enabled(name string) (bool, error) {
+	for _, sc := range SubCollectors {
+		if sc.Name == name {
+			if enabled, ok := o.Collectors[name]; ok {
+				return enabled, nil
+			}
+			return sc.Default, nil
+		}
+	}
+	return false, fmt.Errorf("unknown collector %q", name)
+}
+
+func NewCollector(uri string, opts Options) (*Collector, error) {
 	if strings.HasSuffix(uri, "/") {
 		uri = uri[0 : len(uri)-1]
 	}
-	_, err := url.Parse(uri)
+	u, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
 	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URI %q: expected http(s)://host:port", uri)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: 5 * time.Second,
+		}
+	}
 
-	client := &http.Client{
-		Timeout: timeout,
+	for name := range opts.Collectors {
+		if _, err := opts.enabled(name); err != nil {
+			return nil, err
+		}
+	}
+	plugins, err := newPluginFilter(opts.PluginTypes, opts.PluginInclude, opts.PluginExclude)
+	if err != nil {
+		return nil, err
+	}
+	counters := opts.Counters
+	if counters == nil {
+		counters = NewCounters()
 	}
 
-	return &Collector{
-		URI:    uri + statsPath,
+	c := &Collector{
+		URI:    uri,
 		client: client,
+		opts:   opts,
 		up: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "up",
 			Help:      "Was the last scrape of logstash successful.",
 		}),
-		totalScrapes: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "exporter_total_scrapes",
-			Help:      "Current total logstash scrapes.",
-		}),
-		jsonParseFailures: prometheus.NewCounter(prometheus.CounterOpts{
-			Namespace: namespace,
-			Name:      "exporter_json_parse_failures",
-			Help:      "Number of errors while parsing JSON.",
-		}),
+		totalScrapes:      counters.totalScrapes,
+		jsonParseFailures: counters.jsonParseFailures,
 		logstashStatus: prometheus.NewGauge(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "status",
@@ -81,76 +265,278 @@ func NewCollector(uri string, timeout time.Duration) (*Collector, error) {
 		}),
 		logstashInfo: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "", "info"),
-			"A metric with a constant '1' value labeled by version, http_address, name, id and ephemeral_id from Logstash instance.",
-			[]string{"version", "http_address", "name", "id", "ephemeral_id"},
+			"A metric with a constant '1' value labeled by version, http_address, name, id, ephemeral_id and host from Logstash instance.",
+			[]string{"version", "http_address", "name", "id", "ephemeral_id", "host"},
 			nil,
 		),
-		jvm:            newJVMCollector(),
-		process:        newProcessCollector(),
-		pipelineConfig: newPipelineConfigCollector(),
-		reloadsConfig:  newReloadsConfigCollector(),
-		event:          newEventCollector(),
-		pipeline:       newPipelinesCollector(),
-	}, nil
+		skippedPipelines: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "exporter_skipped_pipelines",
+			Help:      "Number of pipelines skipped by the include and exclude filters in the last scrape.",
+		}),
+		collectorSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "collector_success"),
+			"Whether the sub-collector could fetch its logstash API endpoint in the last scrape. The sub-collectors reading /_node/stats are covered by logstash_up.",
+			[]string{"collector"},
+			nil,
+		),
+	}
+	enabled := func(name string) bool {
+		enabled, _ := opts.enabled(name)
+		return enabled
+	}
+	if enabled("jvm") {
+		c.jvm = newJVMCollector()
+	}
+	if enabled("process") {
+		c.process = newProcessCollector()
+	}
+	if enabled("pipeline_config") {
+		c.pipelineConfig = newPipelineConfigCollector()
+	}
+	if enabled("reloads_config") {
+		c.reloadsConfig = newReloadsConfigCollector()
+	}
+	if enabled("event") {
+		c.event = newEventCollector()
+	}
+	if enabled("pipelines") {
+		c.pipeline = newPipelinesCollector(plugins)
+	}
+	if enabled("os") {
+		c.os = newOSCollector()
+	}
+	if enabled("flow") {
+		c.flow = newFlowCollector("flow", flowHelps)
+	}
+	if enabled("node_info") {
+		c.nodeInfo = newNodeInfoCollector()
+	}
+	if enabled("health_report") {
+		c.healthReport = newHealthReportCollector()
+	}
+	if enabled("pipeline_settings") {
+		c.pipelineSettings = newPipelineSettingsCollector()
+	}
+	if enabled("pipeline_graph") {
+		c.pipelineGraph = newPipelineGraphCollector()
+	}
+	if enabled("plugins") {
+		c.plugins = newPluginsCollector(opts.PluginManifest)
+	} else if len(opts.PluginManifest) > 0 {
+		return nil, errors.New("the plugin manifest requires the plugins collector")
+	}
+	if enabled("hot_threads") {
+		c.hotThreads = newHotThreadsCollector()
+	}
+	return c, nil
 }
 
-// Describe describes all the metrics ever exported by the logstash exporter.
+// Describe describes the fixed metrics of the logstash exporter.
+// The rest are only known after a scrape, and describing them by collecting would query logstash
+// every time the collector is registered, which happens on each probe.
 // It implements prometheus.Collector.
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(c, ch)
+	ch <- c.up.Desc()
+	ch <- c.totalScrapes.Desc()
+	ch <- c.jsonParseFailures.Desc()
+	ch <- c.logstashStatus.Desc()
+	ch <- c.logstashInfo
+	ch <- c.skippedPipelines.Desc()
+	ch <- c.collectorSuccess
 }
 
 // Collect fetches the stats from configured logstash and delivers them as Prometheus metrics.
 // It implements prometheus.Collector.
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(c.subCollectors, ch)
+}
+
+func (c *Collector) collect(s subCollectors, ch chan<- prometheus.Metric) {
 	c.mutex.Lock() // To protect metrics from concurrent collects.
 	defer c.mutex.Unlock()
 
-	c.up.Set(c.scrape(ch))
+	c.up.Set(c.scrape(s, ch))
 
 	ch <- c.up
 	ch <- c.totalScrapes
 	ch <- c.jsonParseFailures
 	ch <- c.logstashStatus
+	ch <- c.skippedPipelines
 }
 
-func (c *Collector) scrape(ch chan<- prometheus.Metric) (up float64) {
-	c.totalScrapes.Inc()
-
-	body, err := c.fetch()
-	if err != nil {
-		logrus.WithError(err).Warnln("can't scrape logstash", statsPath)
-		return 0
+// Filtered returns a collector running only the named sub-collectors, as selected by the collect[] URL parameter.
+// It shares the state of c, like the scrape counters, so it can be built for each request.
+func (c *Collector) Filtered(names []string) (prometheus.Collector, error) {
+	only := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled, err := c.opts.enabled(name)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, fmt.Errorf("collector %q is disabled", name)
+		}
+		only[name] = true
 	}
-	defer body.Close()
+	return &filteredCollector{c: c, subCollectors: c.subCollectors.only(only)}, nil
+}
+
+type filteredCollector struct {
+	c             *Collector
+	subCollectors subCollectors
+}
+
+func (f *filteredCollector) Describe(ch chan<- *prometheus.Desc) {
+	f.c.Describe(ch)
+}
+
+func (f *filteredCollector) Collect(ch chan<- prometheus.Metric) {
+	f.c.collect(f.subCollectors, ch)
+}
+
+func (c *Collector) scrape(s subCollectors, ch chan<- prometheus.Metric) (up float64) {
+	c.totalScrapes.Inc()
 
 	var stats NodeStats
-	if err = json.NewDecoder(body).Decode(&stats); err != nil {
-		logrus.WithError(err).Warn("can't parse json")
-		c.jsonParseFailures.Inc()
+	if err := c.fetchJSON(statsPath, &stats); err != nil {
 		return 0
 	}
 
 	c.logstashStatus.Set(c.getStatus(stats))
 
+	// Skipped pipelines are dropped before any of their series are built.
+	skipped := 0
+	for id := range stats.Pipelines {
+		if !c.opts.pipelineSelected(id) {
+			delete(stats.Pipelines, id)
+			skipped++
+		}
+	}
+	c.skippedPipelines.Set(float64(skipped))
+
 	ch <- prometheus.MustNewConstMetric(c.logstashInfo, prometheus.GaugeValue, 1.0,
 		stats.Version,
 		stats.HttpAddress,
 		stats.Name,
 		stats.ID,
 		stats.EphemeralID,
+		stats.Host,
 	)
 
-	c.jvm.Collect(stats.JVM, ch)
-	c.process.Collect(stats.Process, ch)
-	c.pipelineConfig.Collect(stats.Pipeline, ch)
-	c.reloadsConfig.Collect(stats.Reloads, ch)
-	c.event.Collect(stats.Event, ch)
-	c.pipeline.Collect(stats.Pipelines, ch)
+	if s.jvm != nil {
+		s.jvm.Collect(stats.JVM, ch)
+	}
+	if s.process != nil {
+		s.process.Collect(stats.Process, ch)
+	}
+	if s.pipelineConfig != nil {
+		s.pipelineConfig.Collect(stats.Pipeline, ch)
+	}
+	if s.reloadsConfig != nil {
+		s.reloadsConfig.Collect(stats.Reloads, ch)
+	}
+	if s.event != nil {
+		s.event.Collect(stats.Event, ch)
+	}
+	if s.pipeline != nil {
+		s.pipeline.Collect(stats.Pipelines, ch)
+	}
+	if s.os != nil {
+		s.os.Collect(stats.OS, ch)
+	}
+	if s.flow != nil {
+		s.flow.Collect(stats.Flow, ch)
+	}
+
+	if s.nodeInfo != nil {
+		var err error
+		if c.cachedNodeInfo == nil || c.cachedNodeInfoOwner != stats.EphemeralID {
+			var info NodeInfo
+			if err = c.fetchJSON(nodePath, &info); err == nil {
+				c.cachedNodeInfo, c.cachedNodeInfoOwner = &info, stats.EphemeralID
+			}
+		}
+		if err == nil {
+			s.nodeInfo.Collect(*c.cachedNodeInfo, ch)
+		}
+		c.collectSuccess("node_info", err, ch)
+	}
+	if s.healthReport != nil {
+		var report HealthReport
+		err := c.fetchJSON(healthReportPath, &report)
+		if err == nil {
+			// The pipelines indicator itself still covers all pipelines, like the overall status.
+			for id := range report.Indicators[pipelinesIndicator].Indicators {
+				if !c.opts.pipelineSelected(id) {
+					delete(report.Indicators[pipelinesIndicator].Indicators, id)
+				}
+			}
+			s.healthReport.Collect(report, ch)
+		}
+		c.collectSuccess("health_report", err, ch)
+	}
+	if s.pipelineSettings != nil || s.pipelineGraph != nil {
+		// Both are served by /_node/pipelines, so it is fetched once with the graph only when needed.
+		path := pipelinesPath
+		if s.pipelineGraph != nil {
+			path += "?graph=true"
+		}
+		var pipelines NodePipelines
+		err := c.fetchJSON(path, &pipelines)
+		if err == nil {
+			for id := range pipelines.Pipelines {
+				if !c.opts.pipelineSelected(id) {
+					delete(pipelines.Pipelines, id)
+				}
+			}
+		}
+		if s.pipelineSettings != nil {
+			if err == nil {
+				s.pipelineSettings.Collect(pipelines, ch)
+			}
+			c.collectSuccess("pipeline_settings", err, ch)
+		}
+		if s.pipelineGraph != nil {
+			if err == nil {
+				s.pipelineGraph.Collect(pipelines, ch)
+			}
+			c.collectSuccess("pipeline_graph", err, ch)
+		}
+	}
+	if s.plugins != nil {
+		var plugins NodePlugins
+		err := c.fetchJSON(pluginsPath, &plugins)
+		if err == nil {
+			s.plugins.Collect(plugins, ch)
+		}
+		c.collectSuccess("plugins", err, ch)
+	}
+	if s.hotThreads != nil {
+		var hotThreads HotThreads
+		err := c.fetchJSON(hotThreadsPath, &hotThreads)
+		if err == nil {
+			// The threads outside of pipelines are kept, as the "other" group.
+			threads := hotThreads.HotThreads.Threads[:0]
+			for _, t := range hotThreads.HotThreads.Threads {
+				if id := parseThreadGroup(t.Name).pipeline; id == "" || c.opts.pipelineSelected(id) {
+					threads = append(threads, t)
+				}
+			}
+			hotThreads.HotThreads.Threads = threads
+			s.hotThreads.Collect(hotThreads, ch)
+		}
+		c.collectSuccess("hot_threads", err, ch)
+	}
 
 	return 1
 }
 
+// collectSuccess exports whether the sub-collector could fetch its logstash API endpoint.
+func (c *Collector) collectSuccess(name string, err error, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.collectorSuccess, prometheus.GaugeValue, boolToFloat64(err == nil), name)
+}
+
 func (c *Collector) getStatus(stats NodeStats) float64 {
 	switch stats.Status {
 	case "green":
@@ -162,8 +548,26 @@ func (c *Collector) getStatus(stats NodeStats) float64 {
 	}
 }
 
-func (c *Collector) fetch() (io.ReadCloser, error) {
-	resp, err := c.client.Get(c.URI)
+// fetchJSON decodes the response of the logstash API at path into v.
+// Errors are logged, so that callers only need to skip the metrics of v.
+func (c *Collector) fetchJSON(path string, v interface{}) error {
+	body, err := c.fetch(path)
+	if err != nil {
+		logrus.WithError(err).WithField("path", path).Warn("can't scrape logstash")
+		return err
+	}
+	defer body.Close()
+
+	if err = json.NewDecoder(body).Decode(v); err != nil {
+		logrus.WithError(err).WithField("path", path).Warn("can't parse json")
+		c.jsonParseFailures.Inc()
+		return err
+	}
+	return nil
+}
+
+func (c *Collector) fetch(path string) (io.ReadCloser, error) {
+	resp, err := c.client.Get(c.URI + path)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,265 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// fixtures maps the logstash API paths to the recorded responses under testdata.
+// The query string is ignored, and paths without a fixture respond 404.
+var fixtures = map[string]string{
+	"/_node":             "testdata/node.json",
+	"/_node/stats":       "testdata/node_stats.json",
+	"/_node/plugins":     "testdata/node_plugins.json",
+	"/_node/pipelines":   "testdata/node_pipelines_graph.json",
+	"/_node/hot_threads": "testdata/node_hot_threads.json",
+	"/_health_report":    "testdata/health_report.json",
+}
+
+// newFixtureServer serves the fixtures like logstash. It must be closed by the caller.
+func newFixtureServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(content)
+	}))
+}
+
+// allCollectors enables every sub-collector.
+func allCollectors() map[string]bool {
+	collectors := make(map[string]bool, len(SubCollectors))
+	for _, sc := range SubCollectors {
+		collectors[sc.Name] = true
+	}
+	return collectors
+}
+
+// collectMetrics scrapes c and returns the written metrics.
+func collectMetrics(t *testing.T, c prometheus.Collector) []*dto.Metric {
+	t.Helper()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var metrics []*dto.Metric
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("write %s: %v", m.Desc(), err)
+		}
+		metrics = append(metrics, &out)
+	}
+	return metrics
+}
+
+// descNameRegexp extracts the metric name from the string of a prometheus.Desc.
+var descNameRegexp = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// metricValues runs collect and returns the written values keyed like name{label="value",...}.
+func metricValues(t *testing.T, collect func(ch chan<- prometheus.Metric)) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collect(ch)
+		close(ch)
+	}()
+	values := make(map[string]float64)
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("write %s: %v", m.Desc(), err)
+		}
+		labels := make([]string, 0, len(out.Label))
+		for _, l := range out.Label {
+			labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+		}
+		key := descNameRegexp.FindStringSubmatch(m.Desc().String())[1] + "{" + strings.Join(labels, ",") + "}"
+		switch {
+		case out.Counter != nil:
+			values[key] = out.Counter.GetValue()
+		case out.Gauge != nil:
+			values[key] = out.Gauge.GetValue()
+		case out.Summary != nil:
+			values[key] = out.Summary.GetSampleSum()
+		}
+	}
+	return values
+}
+
+// loadStats reads the /_node/stats fixture.
+func loadStats(t *testing.T) NodeStats {
+	t.Helper()
+	content, err := ioutil.ReadFile(fixtures[statsPath])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats NodeStats
+	if err := json.Unmarshal(content, &stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+// collectorSuccesses scrapes c and returns logstash_exporter_collector_success by collector.
+func collectorSuccesses(c *Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	successes := make(map[string]float64)
+	for m := range ch {
+		if m.Desc() != c.collectorSuccess {
+			continue
+		}
+		var out dto.Metric
+		_ = m.Write(&out)
+		successes[out.Label[0].GetValue()] = out.Gauge.GetValue()
+	}
+	return successes
+}
+
+func TestCollectorSuccess(t *testing.T) {
+	server := newFixtureServer()
+	defer server.Close()
+	c, err := NewCollector(server.URL, Options{Collectors: map[string]bool{"node_info": true, "plugins": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := collectorSuccesses(c); got["node_info"] != 1 || got["plugins"] != 1 || len(got) != 2 {
+		t.Errorf("collector_success = %v, want node_info and plugins succeeded", got)
+	}
+
+	// A missing endpoint, like /_node behind a proxy serving an HTML 404, fails only its sub-collector.
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != statsPath {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("<html>Not Found</html>"))
+			return
+		}
+		content, _ := ioutil.ReadFile(fixtures[statsPath])
+		_, _ = w.Write(content)
+	}))
+	defer notFound.Close()
+	c, err = NewCollector(notFound.URL, Options{Collectors: map[string]bool{"node_info": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := collectorSuccesses(c); got["node_info"] != 0 {
+		t.Errorf("collector_success = %v, want node_info failed", got)
+	}
+	if up := testutil.ToFloat64(c.up); up != 1 {
+		t.Errorf("logstash_up = %v, want 1", up)
+	}
+}
+
+func TestNodeInfoCache(t *testing.T) {
+	ephemeralID := "339d4ddb-8a6e-4ddc-b843-efd4abf4bf73"
+	nodeRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := ioutil.ReadFile(fixtures[r.URL.Path])
+		switch r.URL.Path {
+		case nodePath:
+			nodeRequests++
+		case statsPath:
+			content = bytes.Replace(content, []byte("339d4ddb-8a6e-4ddc-b843-efd4abf4bf73"), []byte(ephemeralID), 1)
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	c, err := NewCollector(server.URL, Options{Collectors: map[string]bool{"node_info": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := collectorSuccesses(c); got["node_info"] != 1 {
+			t.Errorf("collector_success = %v, want node_info succeeded", got)
+		}
+	}
+	if nodeRequests != 1 {
+		t.Errorf("/_node requested %d times, want once while logstash runs", nodeRequests)
+	}
+
+	// A restart of logstash changes the ephemeral_id.
+	ephemeralID = "2a9b3c4d-0000-4ddc-b843-efd4abf4bf73"
+	collectorSuccesses(c)
+	if nodeRequests != 2 {
+		t.Errorf("/_node requested %d times, want again after a restart", nodeRequests)
+	}
+}
+
+func TestPipelineSelection(t *testing.T) {
+	server := newFixtureServer()
+	defer server.Close()
+	c, err := NewCollector(server.URL, Options{
+		Collectors:      map[string]bool{"health_report": true, "hot_threads": true},
+		PipelineExclude: regexp.MustCompile(`^(?:main|pipeline-1)$`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipelines := make(map[string]bool)
+	for _, m := range collectMetrics(t, c) {
+		for _, label := range m.Label {
+			if label.GetName() == "pipeline" {
+				pipelines[label.GetValue()] = true
+			}
+		}
+	}
+	if pipelines["main"] || pipelines["pipeline-1"] {
+		t.Errorf("pipelines = %v, want the excluded ones skipped", pipelines)
+	}
+	if !pipelines[".monitoring-logstash"] || !pipelines[""] {
+		t.Errorf("pipelines = %v, want the hot threads of the other pipelines and outside of pipelines", pipelines)
+	}
+}
+
+func TestLabelNames(t *testing.T) {
+	server := newFixtureServer()
+	defer server.Close()
+	c, err := NewCollector(server.URL, Options{
+		Collectors:     allCollectors(),
+		PluginManifest: map[string]string{"logstash-input-beats": "6.8.0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := make(map[string]bool, len(LabelNames))
+	for _, name := range LabelNames {
+		known[name] = true
+	}
+	for _, m := range collectMetrics(t, c) {
+		for _, label := range m.Label {
+			if !known[label.GetName()] {
+				t.Errorf("label %q is missing from LabelNames", label.GetName())
+			}
+		}
+	}
+	if up := testutil.ToFloat64(c.up); up != 1 {
+		t.Errorf("logstash_up = %v, want 1", up)
+	}
+}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var flowHelps = map[string]string{
+	"input_throughput":              "The events per second received by inputs.",
+	"filter_throughput":             "The events per second processed by filters.",
+	"output_throughput":             "The events per second sent by outputs.",
+	"queue_backpressure":            "The average number of inputs blocked pushing events to the queue.",
+	"worker_concurrency":            "The average number of busy workers.",
+	"worker_utilization":            "The percentage of the available worker time spent processing events.",
+	"queue_persisted_growth_bytes":  "The growth of the persisted queue in bytes per second.",
+	"queue_persisted_growth_events": "The growth of the persisted queue in events per second.",
+}
+
+var inputFlowHelps = map[string]string{
+	"throughput": "The events per second received by the input.",
+}
+
+var workerFlowHelps = map[string]string{
+	"worker_millis_per_event": "The average worker time in milliseconds spent in the plugin per event.",
+	"worker_utilization":      "The percentage of the available worker time spent in the plugin.",
+}
+
+// flowCollector exports the flow metrics of logstash 8.5+ as gauges labeled by window,
+// e.g. current, last_1_minute and lifetime.
+type flowCollector struct {
+	namespace string
+	subsystem string
+	labels    []string
+	helps     map[string]string
+	descs     map[string]*prometheus.Desc
+}
+
+func newFlowCollector(subsystem string, helps map[string]string, labels ...string) *flowCollector {
+	c := &flowCollector{
+		namespace: namespace,
+		subsystem: subsystem,
+		labels:    append(labels, "window"),
+		helps:     helps,
+		descs:     make(map[string]*prometheus.Desc, len(helps)),
+	}
+	for name := range helps {
+		c.descs[name] = c.newDesc(name)
+	}
+	return c
+}
+
+func (c *flowCollector) newDesc(name string) *prometheus.Desc {
+	help, ok := c.helps[name]
+	if !ok {
+		help = "The logstash flow metric " + name + "."
+	}
+	return prometheus.NewDesc(prometheus.BuildFQName(c.namespace, c.subsystem, sanitizeMetricName(name)), help, c.labels, nil)
+}
+
+func (c *flowCollector) Collect(flow map[string]FlowMetric, ch chan<- prometheus.Metric, labelValues ...string) {
+	for name, windows := range flow {
+		desc, ok := c.descs[name]
+		if !ok {
+			desc = c.newDesc(name)
+		}
+		for window, value := range windows {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, append(labelValues, window)...)
+		}
+	}
+}
+
+// sanitizeMetricName replaces the characters not allowed in a metric name.
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFlowCollect(t *testing.T) {
+	stats := loadStats(t)
+	c := newFlowCollector("flow", flowHelps)
+	got := metricValues(t, func(ch chan<- prometheus.Metric) { c.Collect(stats.Flow, ch) })
+
+	want := map[string]float64{
+		`logstash_flow_input_throughput{window="current"}`:       12.5,
+		`logstash_flow_input_throughput{window="last_1_minute"}`: 11,
+		`logstash_flow_input_throughput{window="lifetime"}`:      9.8,
+		`logstash_flow_worker_utilization{window="current"}`:     30.1,
+		// Unknown flows are exported with a sanitized name.
+		`logstash_flow_some_new_flow{window="current"}`: 1,
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+	// The non-numeric "Infinity" window is skipped.
+	if _, ok := got[`logstash_flow_worker_utilization{window="lifetime"}`]; ok {
+		t.Error("the non-numeric lifetime window is exported")
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d metrics, want %d: %v", len(got), len(want), got)
+	}
+}
+
+func TestSanitizeMetricName(t *testing.T) {
+	tests := map[string]string{
+		"worker_utilization": "worker_utilization",
+		"some-new_flow":      "some_new_flow",
+		"queue.growth/s":     "queue_growth_s",
+	}
+	for name, want := range tests {
+		if got := sanitizeMetricName(name); got != want {
+			t.Errorf("sanitizeMetricName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
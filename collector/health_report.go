@@ -0,0 +1,59 @@
+package collector
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// pipelinesIndicator is the health report indicator holding one nested indicator per pipeline.
+const pipelinesIndicator = "pipelines"
+
+// healthStatuses are the statuses of the health report, exported as a state set.
+var healthStatuses = []string{"green", "yellow", "red", "unknown"}
+
+type healthReportCollector struct {
+	status             *prometheus.Desc
+	indicatorStatus    *prometheus.Desc
+	indicatorDiagnoses *prometheus.Desc
+	pipelineStatus     *prometheus.Desc
+	pipelineDiagnoses  *prometheus.Desc
+}
+
+func newHealthReportCollector() *healthReportCollector {
+	desc := newDescFunc(namespace, "health_report")
+	return &healthReportCollector{
+		status:             desc("status", "Whether the overall health of logstash is the given status.", "status"),
+		indicatorStatus:    desc("indicator_status", "Whether the health indicator is the given status.", "indicator", "status"),
+		indicatorDiagnoses: desc("indicator_diagnoses", "The number of diagnoses of the health indicator.", "indicator"),
+		pipelineStatus:     desc("pipeline_status", "Whether the health of the pipeline is the given status.", "pipeline", "status"),
+		pipelineDiagnoses:  desc("pipeline_diagnoses", "The number of diagnoses of the pipeline health.", "pipeline"),
+	}
+}
+
+func (c *healthReportCollector) Collect(r HealthReport, ch chan<- prometheus.Metric) {
+	collectHealthStatus(c.status, r.Status, ch)
+	for name, indicator := range r.Indicators {
+		collectHealthStatus(c.indicatorStatus, indicator.Status, ch, name)
+		ch <- prometheus.MustNewConstMetric(c.indicatorDiagnoses, prometheus.GaugeValue, float64(len(indicator.Diagnosis)), name)
+		if name != pipelinesIndicator {
+			continue
+		}
+		for pipelineName, pipeline := range indicator.Indicators {
+			collectHealthStatus(c.pipelineStatus, pipeline.Status, ch, pipelineName)
+			ch <- prometheus.MustNewConstMetric(c.pipelineDiagnoses, prometheus.GaugeValue, float64(len(pipeline.Diagnosis)), pipelineName)
+		}
+	}
+}
+
+// collectHealthStatus exports 1 for the given status and 0 for the others.
+func collectHealthStatus(desc *prometheus.Desc, status string, ch chan<- prometheus.Metric, labelValues ...string) {
+	known := false
+	for _, s := range healthStatuses {
+		v := 0.0
+		if s == status {
+			v = 1.0
+			known = true
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, append(labelValues, s)...)
+	}
+	if !known && status != "" {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1.0, append(labelValues, status)...)
+	}
+}
@@ -1,9 +1,37 @@
 package collector
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 func newDescFunc(namespace, subsystem string) func(name, help string, labels ...string) *prometheus.Desc {
 	return func(name, help string, labels ...string) *prometheus.Desc {
 		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
 	}
 }
+
+// sanitizeLabelValue collapses whitespace and drops control characters of free-form text like error messages,
+// and truncates it to maxLen runes so it is usable as a label value.
+func sanitizeLabelValue(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if (unicode.IsControl(r) && !unicode.IsSpace(r)) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxLen {
+		s = string(r[:maxLen-3]) + "..."
+	}
+	return s
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
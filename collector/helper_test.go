@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeLabelValue(t *testing.T) {
+	long := strings.Repeat("é", maxErrorMessageLength+1)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"empty", "", ""},
+		{"plain", "Couldn't find any filter plugin", "Couldn't find any filter plugin"},
+		{"whitespace", "  Expected one of\n\t[ #, input ]\r\n", "Expected one of [ #, input ]"},
+		{"control characters", "bad\x00 byte\x1b[31m �", "bad byte[31m"},
+		{"exact length", strings.Repeat("a", maxErrorMessageLength), strings.Repeat("a", maxErrorMessageLength)},
+		{"too long", long, strings.Repeat("é", maxErrorMessageLength-3) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeLabelValue(tt.in, maxErrorMessageLength)
+			if got != tt.want {
+				t.Errorf("sanitizeLabelValue(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > maxErrorMessageLength {
+				t.Errorf("sanitizeLabelValue(%q) has %d runes, want at most %d", tt.in, n, maxErrorMessageLength)
+			}
+		})
+	}
+}
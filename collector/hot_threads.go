@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// threadNameRegexp matches the pipeline threads, like "[main]>worker3" or "[main]<beats".
+var threadNameRegexp = regexp.MustCompile(`^\[([^\]]+)\]([<>])?(.*)$`)
+
+var workerNameRegexp = regexp.MustCompile(`^worker\d+$`)
+
+type hotThreadsCollector struct {
+	CPUPercent *prometheus.Desc
+	Threads    *prometheus.Desc
+}
+
+func newHotThreadsCollector() *hotThreadsCollector {
+	desc := newDescFunc(namespace, "hot_threads")
+	labels := []string{"pipeline", "group", "plugin"}
+	return &hotThreadsCollector{
+		CPUPercent: desc("cpu_percent", "The sum of the CPU time percentage of the busiest threads in the group.", labels...),
+		Threads:    desc("threads", "The number of the busiest threads in the group by state.", append(labels, "state")...),
+	}
+}
+
+type threadGroup struct {
+	pipeline, group, plugin string
+}
+
+type threadGroupStats struct {
+	cpuPercent float64
+	states     map[string]int
+}
+
+// parseThreadGroup groups a thread by its name. Pipeline workers are named "[pipeline]>workerN",
+// inputs "[pipeline]<plugin" and the other threads of outputs "[pipeline]>plugin".
+func parseThreadGroup(name string) threadGroup {
+	m := threadNameRegexp.FindStringSubmatch(name)
+	if m == nil {
+		return threadGroup{group: "other"}
+	}
+	switch {
+	case m[2] == ">" && workerNameRegexp.MatchString(m[3]):
+		return threadGroup{pipeline: m[1], group: "worker"}
+	case m[2] == "<":
+		return threadGroup{pipeline: m[1], group: "input", plugin: m[3]}
+	case m[2] == ">":
+		return threadGroup{pipeline: m[1], group: "output", plugin: m[3]}
+	default:
+		return threadGroup{pipeline: m[1], group: "other"}
+	}
+}
+
+func (c *hotThreadsCollector) Collect(h HotThreads, ch chan<- prometheus.Metric) {
+	groups := make(map[threadGroup]*threadGroupStats)
+	for _, t := range h.HotThreads.Threads {
+		g := parseThreadGroup(t.Name)
+		s, ok := groups[g]
+		if !ok {
+			s = &threadGroupStats{states: make(map[string]int)}
+			groups[g] = s
+		}
+		s.cpuPercent += t.PercentOfCPUTime
+		s.states[strings.ToLower(t.State)]++
+	}
+
+	for g, s := range groups {
+		ch <- prometheus.MustNewConstMetric(c.CPUPercent, prometheus.GaugeValue, s.cpuPercent, g.pipeline, g.group, g.plugin)
+		for state, n := range s.states {
+			ch <- prometheus.MustNewConstMetric(c.Threads, prometheus.GaugeValue, float64(n), g.pipeline, g.group, g.plugin, state)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestParseThreadGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		want threadGroup
+	}{
+		{"[main]>worker0", threadGroup{pipeline: "main", group: "worker"}},
+		{"[main]>worker12", threadGroup{pipeline: "main", group: "worker"}},
+		{"[main]<beats", threadGroup{pipeline: "main", group: "input", plugin: "beats"}},
+		{"[.monitoring-logstash]<metrics", threadGroup{pipeline: ".monitoring-logstash", group: "input", plugin: "metrics"}},
+		{"[main]>elasticsearch", threadGroup{pipeline: "main", group: "output", plugin: "elasticsearch"}},
+		{"[main]-pipeline-manager", threadGroup{pipeline: "main", group: "other"}},
+		{"Ruby-0-Thread-9", threadGroup{group: "other"}},
+		{"pool-3-thread-1", threadGroup{group: "other"}},
+		{"", threadGroup{group: "other"}},
+	}
+	for _, tt := range tests {
+		if got := parseThreadGroup(tt.name); got != tt.want {
+			t.Errorf("parseThreadGroup(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHotThreadsCollect(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/node_hot_threads.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h HotThreads
+	if err := json.Unmarshal(content, &h); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newHotThreadsCollector()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(h, ch)
+		close(ch)
+	}()
+	cpuPercent := make(map[threadGroup]float64)
+	threads := make(map[string]float64)
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatal(err)
+		}
+		labels := make(map[string]string)
+		for _, l := range out.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		g := threadGroup{pipeline: labels["pipeline"], group: labels["group"], plugin: labels["plugin"]}
+		switch m.Desc() {
+		case c.CPUPercent:
+			cpuPercent[g] = out.Gauge.GetValue()
+		case c.Threads:
+			threads[g.pipeline+"/"+g.group+"/"+labels["state"]] = out.Gauge.GetValue()
+		}
+	}
+
+	wantCPUPercent := map[threadGroup]float64{
+		{pipeline: "main", group: "worker"}:                                   19.75,
+		{pipeline: "main", group: "input", plugin: "beats"}:                   3.1,
+		{pipeline: "main", group: "output", plugin: "elasticsearch"}:          1.02,
+		{pipeline: ".monitoring-logstash", group: "input", plugin: "metrics"}: 0.4,
+		{group: "other"}: 0.25,
+	}
+	if len(cpuPercent) != len(wantCPUPercent) {
+		t.Errorf("cpu_percent = %v, want %v", cpuPercent, wantCPUPercent)
+	}
+	for g, want := range wantCPUPercent {
+		if got := cpuPercent[g]; got != want {
+			t.Errorf("cpu_percent%+v = %v, want %v", g, got, want)
+		}
+	}
+	if got := threads["main/worker/runnable"]; got != 1 {
+		t.Errorf("runnable workers = %v, want 1", got)
+	}
+	if got := threads["main/worker/waiting"]; got != 1 {
+		t.Errorf("waiting workers = %v, want 1", got)
+	}
+}
@@ -1,51 +1,79 @@
 package collector
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type jvmCollector struct {
-	threadsCount         *prometheus.Desc
-	heapUsedRatio        *prometheus.Desc
-	heapCommittedInBytes *prometheus.Desc
-	heapUsedInBytes      *prometheus.Desc
-	poolUsedBytes        *prometheus.Desc
-	poolCommittedBytes   *prometheus.Desc
-	poolMaxBytes         *prometheus.Desc
-	gc                   *prometheus.Desc
+	threadsCount            *prometheus.Desc
+	threadsPeakCount        *prometheus.Desc
+	heapUsedRatio           *prometheus.Desc
+	heapCommittedInBytes    *prometheus.Desc
+	heapMaxInBytes          *prometheus.Desc
+	heapUsedInBytes         *prometheus.Desc
+	nonHeapCommittedInBytes *prometheus.Desc
+	nonHeapUsedInBytes      *prometheus.Desc
+	poolUsedBytes           *prometheus.Desc
+	poolPeakUsedBytes       *prometheus.Desc
+	poolCommittedBytes      *prometheus.Desc
+	poolMaxBytes            *prometheus.Desc
+	poolPeakMaxBytes        *prometheus.Desc
+	gc                      *prometheus.Desc
+	uptime                  *prometheus.Desc
+	startTime               *prometheus.Desc
 }
 
 func newJVMCollector() *jvmCollector {
 	desc := newDescFunc(namespace, "jvm")
 	return &jvmCollector{
-		threadsCount:         desc("threads_count", "Current JVM thread count."),
-		heapUsedRatio:        desc("heap_used_ratio", "Current JVM heap usage ratio."),
-		heapCommittedInBytes: desc("heap_committed_bytes", "Current JVM heap committed size"),
-		heapUsedInBytes:      desc("heap_used_bytes", "Current JVM heap used size"),
-		poolUsedBytes:        desc("memory_pool_used_bytes", "Current JVM heap pool used size", "pool"),
-		poolCommittedBytes:   desc("memory_pool_committed_bytes", "Current JVM heap pool committed size", "pool"),
-		poolMaxBytes:         desc("memory_pool_max_bytes", "Current JVM heap pool max size", "pool"),
-		gc:                   desc("gc_collection_duration_seconds", "GC collection duration.", "collector"),
+		threadsCount:            desc("threads_count", "Current JVM thread count."),
+		threadsPeakCount:        desc("threads_peak_count", "Peak JVM thread count."),
+		heapUsedRatio:           desc("heap_used_ratio", "Current JVM heap usage ratio."),
+		heapCommittedInBytes:    desc("heap_committed_bytes", "Current JVM heap committed size"),
+		heapMaxInBytes:          desc("heap_max_bytes", "JVM heap max size"),
+		heapUsedInBytes:         desc("heap_used_bytes", "Current JVM heap used size"),
+		nonHeapCommittedInBytes: desc("non_heap_committed_bytes", "Current JVM non-heap committed size"),
+		nonHeapUsedInBytes:      desc("non_heap_used_bytes", "Current JVM non-heap used size"),
+		poolUsedBytes:           desc("memory_pool_used_bytes", "Current JVM heap pool used size", "pool"),
+		poolPeakUsedBytes:       desc("memory_pool_peak_used_bytes", "Peak JVM heap pool used size", "pool"),
+		poolCommittedBytes:      desc("memory_pool_committed_bytes", "Current JVM heap pool committed size", "pool"),
+		poolMaxBytes:            desc("memory_pool_max_bytes", "Current JVM heap pool max size", "pool"),
+		poolPeakMaxBytes:        desc("memory_pool_peak_max_bytes", "Peak JVM heap pool max size", "pool"),
+		gc:                      desc("gc_collection_duration_seconds", "GC collection duration.", "collector"),
+		uptime:                  desc("uptime_seconds", "JVM uptime in seconds."),
+		startTime:               desc("start_time_seconds", "Start time of the logstash JVM since unix epoch in seconds, derived from the uptime."),
 	}
 }
 
 func (c *jvmCollector) Collect(jvm JVM, ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(c.threadsCount, prometheus.GaugeValue, float64(jvm.Threads.Count))
+	ch <- prometheus.MustNewConstMetric(c.threadsPeakCount, prometheus.GaugeValue, float64(jvm.Threads.PeakCount))
 
 	ch <- prometheus.MustNewConstMetric(c.heapUsedRatio, prometheus.GaugeValue, float64(jvm.Mem.HeapUsedPercent)/100.0)
 	ch <- prometheus.MustNewConstMetric(c.heapCommittedInBytes, prometheus.GaugeValue, float64(jvm.Mem.HeapCommittedInBytes))
+	ch <- prometheus.MustNewConstMetric(c.heapMaxInBytes, prometheus.GaugeValue, float64(jvm.Mem.HeapMaxInBytes))
 	ch <- prometheus.MustNewConstMetric(c.heapUsedInBytes, prometheus.GaugeValue, float64(jvm.Mem.HeapUsedInBytes))
+	ch <- prometheus.MustNewConstMetric(c.nonHeapCommittedInBytes, prometheus.GaugeValue, float64(jvm.Mem.NonHeapCommittedInBytes))
+	ch <- prometheus.MustNewConstMetric(c.nonHeapUsedInBytes, prometheus.GaugeValue, float64(jvm.Mem.NonHeapUsedInBytes))
 
-	ch <- prometheus.MustNewConstMetric(c.poolUsedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Young.UsedInBytes), "young")
-	ch <- prometheus.MustNewConstMetric(c.poolUsedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Survivor.UsedInBytes), "survivor")
-	ch <- prometheus.MustNewConstMetric(c.poolUsedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Old.UsedInBytes), "old")
-
-	ch <- prometheus.MustNewConstMetric(c.poolCommittedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Young.CommittedInBytes), "young")
-	ch <- prometheus.MustNewConstMetric(c.poolCommittedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Survivor.CommittedInBytes), "survivor")
-	ch <- prometheus.MustNewConstMetric(c.poolCommittedBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Old.CommittedInBytes), "old")
+	for name, pool := range jvm.Mem.Pools {
+		ch <- prometheus.MustNewConstMetric(c.poolUsedBytes, prometheus.GaugeValue, float64(pool.UsedInBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.poolPeakUsedBytes, prometheus.GaugeValue, float64(pool.PeakUsedInBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.poolCommittedBytes, prometheus.GaugeValue, float64(pool.CommittedInBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.poolMaxBytes, prometheus.GaugeValue, float64(pool.MaxInBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.poolPeakMaxBytes, prometheus.GaugeValue, float64(pool.PeakMaxInBytes), name)
+	}
 
-	ch <- prometheus.MustNewConstMetric(c.poolMaxBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Young.MaxInBytes), "young")
-	ch <- prometheus.MustNewConstMetric(c.poolMaxBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Survivor.MaxInBytes), "survivor")
-	ch <- prometheus.MustNewConstMetric(c.poolMaxBytes, prometheus.GaugeValue, float64(jvm.Mem.Pools.Old.MaxInBytes), "old")
+	for name, gc := range jvm.GC.Collectors {
+		ch <- prometheus.MustNewConstSummary(c.gc, gc.CollectionCount, float64(gc.CollectionTimeInMillis)/1000.0, nil, name)
+	}
 
-	ch <- prometheus.MustNewConstSummary(c.gc, jvm.GC.Collectors.Young.CollectionCount, float64(jvm.GC.Collectors.Young.CollectionTimeInMillis)/1000.0, nil, "young")
-	ch <- prometheus.MustNewConstSummary(c.gc, jvm.GC.Collectors.Old.CollectionCount, float64(jvm.GC.Collectors.Old.CollectionTimeInMillis)/1000.0, nil, "old")
+	uptime := float64(jvm.UptimeInMillis) / 1000.0
+	ch <- prometheus.MustNewConstMetric(c.uptime, prometheus.GaugeValue, uptime)
+	// Rounded to the second, as the uptime is behind the current time by the request latency.
+	startTime := math.Round(float64(time.Now().UnixNano())/1e9 - uptime)
+	ch <- prometheus.MustNewConstMetric(c.startTime, prometheus.GaugeValue, startTime)
 }
@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestJVMStartTime(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/node_stats.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats NodeStats
+	if err := json.Unmarshal(content, &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newJVMCollector()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(stats.JVM, ch)
+		close(ch)
+	}()
+	var startTime float64
+	for m := range ch {
+		if m.Desc() != c.startTime {
+			continue
+		}
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatal(err)
+		}
+		startTime = out.Gauge.GetValue()
+	}
+
+	want := float64(time.Now().Unix()) - float64(stats.JVM.UptimeInMillis)/1000.0
+	if startTime != math.Round(startTime) || math.Abs(startTime-want) > 2 {
+		t.Errorf("start_time_seconds = %v, want about %v in whole seconds", startTime, want)
+	}
+}
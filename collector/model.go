@@ -1,5 +1,11 @@
 package collector
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type NodeStats struct {
 	// Top level
 	Host        string `json:"host"`
@@ -16,6 +22,9 @@ type NodeStats struct {
 	Process   Process             `json:"process"`
 	Event     Event               `json:"events"`
 	Pipelines map[string]Pipeline `json:"pipelines"`
+	OS        OS                  `json:"os"`
+	// Only reported by logstash 8.5+.
+	Flow map[string]FlowMetric `json:"flow"`
 }
 
 type PipelineConfig struct {
@@ -47,25 +56,80 @@ type Process struct {
 	} `json:"cpu"`
 }
 
+type OS struct {
+	// Only reported when logstash finds the cgroup controllers. On cgroup v2 hosts, the same fields are read
+	// from cpu.max and cpu.stat of the unified hierarchy: the quota is "max" when unlimited,
+	// and cpuacct is missing when cpu.stat has no usage.
+	Cgroup *struct {
+		CPUAcct *struct {
+			ControlGroup string `json:"control_group"`
+			UsageNanos   int    `json:"usage_nanos"`
+		} `json:"cpuacct"`
+		CPU struct {
+			ControlGroup    string      `json:"control_group"`
+			CfsPeriodMicros int         `json:"cfs_period_micros"`
+			CfsQuotaMicros  CgroupLimit `json:"cfs_quota_micros"`
+			Stat            struct {
+				NumberOfElapsedPeriods int `json:"number_of_elapsed_periods"`
+				NumberOfTimesThrottled int `json:"number_of_times_throttled"`
+				TimeThrottledNanos     int `json:"time_throttled_nanos"`
+			} `json:"stat"`
+		} `json:"cpu"`
+	} `json:"cgroup"`
+}
+
+// CgroupLimit is a cgroup limit, which is -1 when unlimited. The "max" of cgroup v2 is also read as unlimited.
+type CgroupLimit int
+
+func (l *CgroupLimit) UnmarshalJSON(data []byte) error {
+	if string(data) == `"max"` {
+		*l = -1
+		return nil
+	}
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*l = CgroupLimit(v)
+	return nil
+}
+
+// FlowMetric holds the rates of a flow metric by window, e.g. current, last_1_minute and lifetime.
+type FlowMetric map[string]float64
+
+// UnmarshalJSON skips the windows without a numeric value, so that they don't fail the whole scrape.
+func (f *FlowMetric) UnmarshalJSON(data []byte) error {
+	var windows map[string]interface{}
+	if err := json.Unmarshal(data, &windows); err != nil {
+		return err
+	}
+	*f = make(FlowMetric, len(windows))
+	for window, value := range windows {
+		if v, ok := value.(float64); ok {
+			(*f)[window] = v
+		}
+	}
+	return nil
+}
+
 type JVM struct {
 	Threads struct {
-		Count int `json:"count"`
+		Count     int `json:"count"`
+		PeakCount int `json:"peak_count"`
 	} `json:"threads"`
 	Mem struct {
-		HeapUsedPercent      int `json:"heap_used_percent"`
-		HeapCommittedInBytes int `json:"heap_committed_in_bytes"`
-		HeapUsedInBytes      int `json:"heap_used_in_bytes"`
-		Pools                struct {
-			Survivor JvmPool `json:"survivor"`
-			Old      JvmPool `json:"old"`
-			Young    JvmPool `json:"young"`
-		} `json:"pools"`
+		HeapUsedPercent         int `json:"heap_used_percent"`
+		HeapCommittedInBytes    int `json:"heap_committed_in_bytes"`
+		HeapMaxInBytes          int `json:"heap_max_in_bytes"`
+		HeapUsedInBytes         int `json:"heap_used_in_bytes"`
+		NonHeapUsedInBytes      int `json:"non_heap_used_in_bytes"`
+		NonHeapCommittedInBytes int `json:"non_heap_committed_in_bytes"`
+		// Pools are keyed by the name logstash reports, e.g. young, survivor and old.
+		Pools map[string]JvmPool `json:"pools"`
 	} `json:"mem"`
 	GC struct {
-		Collectors struct {
-			Old   GCCollector `json:"old"`
-			Young GCCollector `json:"young"`
-		} `json:"collectors"`
+		// Collectors are keyed by the name logstash reports, e.g. young and old.
+		Collectors map[string]GCCollector `json:"collectors"`
 	} `json:"gc"`
 	UptimeInMillis int `json:"uptime_in_millis"`
 }
@@ -92,18 +156,71 @@ type GCCollector struct {
 }
 
 type Pipeline struct {
-	Event   Event `json:"events"`
+	Event   Event           `json:"events"`
+	Reloads PipelineReloads `json:"reloads"`
 	Plugins struct {
 		Inputs  []InputPlugin  `json:"inputs"`
 		Filters []FilterPlugin `json:"filters"`
 		Outputs []OutputPlugin `json:"outputs"`
+		Codecs  []CodecPlugin  `json:"codecs"`
 	} `json:"plugins"`
 	Queue struct {
 		Type                string `json:"type"`
 		EventsCount         int    `json:"events_count"`
 		QueueSizeInBytes    int    `json:"queue_size_in_bytes"`
 		MaxQueueSizeInBytes int    `json:"max_queue_size_in_bytes"`
+
+		// Only reported by persisted queues.
+		Capacity *QueueCapacity `json:"capacity"`
+		Data     *QueueData     `json:"data"`
 	} `json:"queue"`
+	// Only reported by logstash 8.5+.
+	Flow map[string]FlowMetric `json:"flow"`
+	// Only reported when dead_letter_queue.enable is on.
+	DeadLetterQueue *DeadLetterQueue `json:"dead_letter_queue"`
+}
+
+type QueueCapacity struct {
+	PageCapacityInBytes int `json:"page_capacity_in_bytes"`
+	MaxQueueSizeInBytes int `json:"max_queue_size_in_bytes"`
+	MaxUnreadEvents     int `json:"max_unread_events"`
+	QueueSizeInBytes    int `json:"queue_size_in_bytes"`
+}
+
+type QueueData struct {
+	FreeSpaceInBytes int    `json:"free_space_in_bytes"`
+	StorageType      string `json:"storage_type"`
+	Path             string `json:"path"`
+}
+
+type DeadLetterQueue struct {
+	QueueSizeInBytes    int    `json:"queue_size_in_bytes"`
+	MaxQueueSizeInBytes int    `json:"max_queue_size_in_bytes"`
+	DroppedEvents       int    `json:"dropped_events"`
+	ExpiredEvents       int    `json:"expired_events"`
+	LastError           string `json:"last_error"`
+	StoragePolicy       string `json:"storage_policy"`
+}
+
+type PipelineReloads struct {
+	Failures             int          `json:"failures"`
+	Successes            int          `json:"successes"`
+	LastError            *ReloadError `json:"last_error"`
+	LastSuccessTimestamp *time.Time   `json:"last_success_timestamp"`
+	LastFailureTimestamp *time.Time   `json:"last_failure_timestamp"`
+}
+
+// ReloadError is reported as an object with a message and backtrace, or by some versions as a plain message.
+type ReloadError struct {
+	Message string `json:"message"`
+}
+
+func (e *ReloadError) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &e.Message)
+	}
+	type plain ReloadError
+	return json.Unmarshal(data, (*plain)(e))
 }
 
 type InputPlugin struct {
@@ -114,6 +231,8 @@ type InputPlugin struct {
 		QueuePushDurationInMillis int `json:"queue_push_duration_in_millis"`
 		Out                       int `json:"out"`
 	} `json:"events"`
+	// Only reported by logstash 8.5+.
+	Flow map[string]FlowMetric `json:"flow"`
 }
 
 type FilterPlugin struct {
@@ -124,6 +243,13 @@ type FilterPlugin struct {
 		DurationInMillis int `json:"duration_in_millis"`
 		Out              int `json:"out"`
 	} `json:"events"`
+
+	// Only reported by filters matching events, such as date, grok and dissect.
+	Matches  *int `json:"matches"`
+	Failures *int `json:"failures"`
+
+	// Only reported by logstash 8.5+.
+	Flow map[string]FlowMetric `json:"flow"`
 }
 
 type OutputPlugin struct {
@@ -134,4 +260,170 @@ type OutputPlugin struct {
 		DurationInMillis int `json:"duration_in_millis"`
 		Out              int `json:"out"`
 	} `json:"events"`
+
+	// Only reported by the elasticsearch output.
+	Documents    *OutputDocuments    `json:"documents"`
+	BulkRequests *OutputBulkRequests `json:"bulk_requests"`
+
+	// Only reported by logstash 8.5+.
+	Flow map[string]FlowMetric `json:"flow"`
+}
+
+type OutputDocuments struct {
+	Successes            int `json:"successes"`
+	NonRetryableFailures int `json:"non_retryable_failures"`
+	DlqRouted            int `json:"dlq_routed"`
+}
+
+type OutputBulkRequests struct {
+	Successes  int `json:"successes"`
+	WithErrors int `json:"with_errors"`
+	Failures   int `json:"failures"`
+	// Responses counts the bulk responses by HTTP status code.
+	Responses map[string]int `json:"responses"`
+}
+
+type CodecPlugin struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Decode struct {
+		WritesIn         int `json:"writes_in"`
+		DurationInMillis int `json:"duration_in_millis"`
+		Out              int `json:"out"`
+	} `json:"decode"`
+	Encode struct {
+		WritesIn         int `json:"writes_in"`
+		DurationInMillis int `json:"duration_in_millis"`
+	} `json:"encode"`
+}
+
+type HealthReport struct {
+	Status     string                           `json:"status"`
+	Symptom    string                           `json:"symptom"`
+	Indicators map[string]HealthReportIndicator `json:"indicators"`
+}
+
+type HealthReportIndicator struct {
+	Status    string                  `json:"status"`
+	Symptom   string                  `json:"symptom"`
+	Diagnosis []HealthReportDiagnosis `json:"diagnosis"`
+	// Indicators holds the nested indicators, e.g. one per pipeline for the pipelines indicator.
+	Indicators map[string]HealthReportIndicator `json:"indicators"`
+}
+
+type HealthReportDiagnosis struct {
+	ID      string `json:"id"`
+	Cause   string `json:"cause"`
+	Action  string `json:"action"`
+	HelpURL string `json:"help_url"`
+}
+
+type NodePipelines struct {
+	Pipelines map[string]NodePipeline `json:"pipelines"`
+}
+
+type NodePipeline struct {
+	Workers                int  `json:"workers"`
+	BatchSize              int  `json:"batch_size"`
+	BatchDelay             int  `json:"batch_delay"`
+	ConfigReloadAutomatic  bool `json:"config_reload_automatic"`
+	DeadLetterQueueEnabled bool `json:"dead_letter_queue_enabled"`
+	// Only reported by some logstash versions.
+	Ordered          Setting `json:"ordered"`
+	ECSCompatibility Setting `json:"ecs_compatibility"`
+
+	// Only reported with ?graph=true.
+	Graph *struct {
+		Graph PipelineGraph `json:"graph"`
+	} `json:"graph"`
+}
+
+type PipelineGraph struct {
+	Vertices []PipelineVertex `json:"vertices"`
+	Edges    []PipelineEdge   `json:"edges"`
+}
+
+type PipelineVertex struct {
+	ID string `json:"id"`
+	// Type is plugin, if or queue.
+	Type       string `json:"type"`
+	PluginType string `json:"plugin_type"`
+	ConfigName string `json:"config_name"`
+	ExplicitID bool   `json:"explicit_id"`
+	Condition  string `json:"condition"`
+	Meta       *struct {
+		Source struct {
+			Protocol string `json:"protocol"`
+			ID       string `json:"id"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+		} `json:"source"`
+	} `json:"meta"`
+}
+
+type PipelineEdge struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is plain, or boolean for the branches of an if vertex.
+	Type string `json:"type"`
+	When *bool  `json:"when"`
+}
+
+// Setting is a scalar pipeline setting, which logstash reports as a string or a boolean.
+type Setting string
+
+func (s *Setting) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		*s = Setting(fmt.Sprint(v))
+	}
+	return nil
+}
+
+type NodePlugins struct {
+	Plugins []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"plugins"`
+}
+
+type NodeInfo struct {
+	Pipeline PipelineConfig `json:"pipeline"`
+	OS       struct {
+		Name                string `json:"name"`
+		Arch                string `json:"arch"`
+		Version             string `json:"version"`
+		AvailableProcessors int    `json:"available_processors"`
+	} `json:"os"`
+	JVM struct {
+		Version           string `json:"version"`
+		VMName            string `json:"vm_name"`
+		VMVersion         string `json:"vm_version"`
+		VMVendor          string `json:"vm_vendor"`
+		StartTimeInMillis int64  `json:"start_time_in_millis"`
+		Mem               struct {
+			HeapInitInBytes    int64 `json:"heap_init_in_bytes"`
+			HeapMaxInBytes     int64 `json:"heap_max_in_bytes"`
+			NonHeapInitInBytes int64 `json:"non_heap_init_in_bytes"`
+			NonHeapMaxInBytes  int64 `json:"non_heap_max_in_bytes"`
+		} `json:"mem"`
+	} `json:"jvm"`
+}
+
+type HotThreads struct {
+	HotThreads struct {
+		Threads []HotThread `json:"threads"`
+	} `json:"hot_threads"`
+}
+
+type HotThread struct {
+	Name             string  `json:"name"`
+	ThreadID         int64   `json:"thread_id"`
+	PercentOfCPUTime float64 `json:"percent_of_cpu_time"`
+	State            string  `json:"state"`
+	Path             string  `json:"path"`
 }
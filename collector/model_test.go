@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPipelineReloadsUnmarshal(t *testing.T) {
+	success := time.Date(2024, 11, 5, 9, 12, 41, 0, time.UTC)
+	tests := []struct {
+		name        string
+		json        string
+		wantError   *ReloadError
+		wantSuccess *time.Time
+		wantFailure *time.Time
+	}{
+		{
+			name: "never reloaded",
+			json: `{"successes":0,"failures":0,"last_error":null,"last_success_timestamp":null,"last_failure_timestamp":null}`,
+		},
+		{
+			name:        "error object",
+			json:        `{"last_error":{"message":"Expected one of [ \t\r\n]","backtrace":["org/logstash/Compiler.java:42"]},"last_success_timestamp":"2024-11-05T09:12:41Z","last_failure_timestamp":null}`,
+			wantError:   &ReloadError{Message: "Expected one of [ \t\r\n]"},
+			wantSuccess: &success,
+		},
+		{
+			name:        "error message",
+			json:        `{"last_error":"Couldn't find any filter plugin named 'grokk'","last_failure_timestamp":"2024-11-05T09:12:41Z"}`,
+			wantError:   &ReloadError{Message: "Couldn't find any filter plugin named 'grokk'"},
+			wantFailure: &success,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r PipelineReloads
+			if err := json.Unmarshal([]byte(tt.json), &r); err != nil {
+				t.Fatal(err)
+			}
+			if (r.LastError == nil) != (tt.wantError == nil) || (r.LastError != nil && *r.LastError != *tt.wantError) {
+				t.Errorf("last_error = %+v, want %+v", r.LastError, tt.wantError)
+			}
+			if !equalTime(r.LastSuccessTimestamp, tt.wantSuccess) {
+				t.Errorf("last_success_timestamp = %v, want %v", r.LastSuccessTimestamp, tt.wantSuccess)
+			}
+			if !equalTime(r.LastFailureTimestamp, tt.wantFailure) {
+				t.Errorf("last_failure_timestamp = %v, want %v", r.LastFailureTimestamp, tt.wantFailure)
+			}
+		})
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type nodeInfoCollector struct {
+	OSInfo                *prometheus.Desc
+	OSAvailableProcessors *prometheus.Desc
+	JVMInfo               *prometheus.Desc
+	JVMHeapInit           *prometheus.Desc
+	JVMNonHeapInit        *prometheus.Desc
+	JVMNonHeapMax         *prometheus.Desc
+	PipelineDefaultsInfo  *prometheus.Desc
+}
+
+func newNodeInfoCollector() *nodeInfoCollector {
+	desc := newDescFunc(namespace, "node")
+	return &nodeInfoCollector{
+		OSInfo:                desc("os_info", "A metric with a constant '1' value labeled by name, arch and version of the OS logstash runs on.", "name", "arch", "version"),
+		OSAvailableProcessors: desc("os_available_processors", "The number of processors available to the JVM."),
+		JVMInfo:               desc("jvm_info", "A metric with a constant '1' value labeled by version, vm_name, vm_vendor and vm_version of the JVM.", "version", "vm_name", "vm_vendor", "vm_version"),
+		JVMHeapInit:           desc("jvm_heap_init_bytes", "The initial JVM heap size in bytes."),
+		JVMNonHeapInit:        desc("jvm_non_heap_init_bytes", "The initial JVM non-heap size in bytes."),
+		JVMNonHeapMax:         desc("jvm_non_heap_max_bytes", "The maximum JVM non-heap size in bytes, or -1 if unbounded."),
+		PipelineDefaultsInfo:  desc("pipeline_defaults_info", "A metric with a constant '1' value labeled by the default workers, batch_size and batch_delay_seconds of pipelines.", "workers", "batch_size", "batch_delay_seconds"),
+	}
+}
+
+func (c *nodeInfoCollector) Collect(n NodeInfo, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.OSInfo, prometheus.GaugeValue, 1.0, n.OS.Name, n.OS.Arch, n.OS.Version)
+	ch <- prometheus.MustNewConstMetric(c.OSAvailableProcessors, prometheus.GaugeValue, float64(n.OS.AvailableProcessors))
+
+	ch <- prometheus.MustNewConstMetric(c.JVMInfo, prometheus.GaugeValue, 1.0, n.JVM.Version, n.JVM.VMName, n.JVM.VMVendor, n.JVM.VMVersion)
+	ch <- prometheus.MustNewConstMetric(c.JVMHeapInit, prometheus.GaugeValue, float64(n.JVM.Mem.HeapInitInBytes))
+	ch <- prometheus.MustNewConstMetric(c.JVMNonHeapInit, prometheus.GaugeValue, float64(n.JVM.Mem.NonHeapInitInBytes))
+	ch <- prometheus.MustNewConstMetric(c.JVMNonHeapMax, prometheus.GaugeValue, float64(n.JVM.Mem.NonHeapMaxInBytes))
+
+	ch <- prometheus.MustNewConstMetric(c.PipelineDefaultsInfo, prometheus.GaugeValue, 1.0,
+		strconv.Itoa(n.Pipeline.Workers),
+		strconv.Itoa(n.Pipeline.BatchSize),
+		strconv.FormatFloat(float64(n.Pipeline.BatchDelay)/1000.0, 'f', -1, 64),
+	)
+}
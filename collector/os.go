@@ -0,0 +1,49 @@
+package collector
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type osCollector struct {
+	cpuacctUsage     *prometheus.Desc
+	cfsPeriod        *prometheus.Desc
+	cfsQuota         *prometheus.Desc
+	elapsedPeriods   *prometheus.Desc
+	throttledPeriods *prometheus.Desc
+	throttledTime    *prometheus.Desc
+	throttledRatio   *prometheus.Desc
+}
+
+func newOSCollector() *osCollector {
+	desc := newDescFunc(namespace, "os")
+	return &osCollector{
+		cpuacctUsage:     desc("cgroup_cpuacct_usage_seconds_total", "The total CPU time consumed by all tasks in the cgroup.", "control_group"),
+		cfsPeriod:        desc("cgroup_cpu_cfs_period_seconds", "The period of the CFS CPU quota of the cgroup.", "control_group"),
+		cfsQuota:         desc("cgroup_cpu_cfs_quota_seconds", "The CPU time the cgroup may use per CFS period. Not exported when unlimited.", "control_group"),
+		elapsedPeriods:   desc("cgroup_cpu_elapsed_periods_total", "The total number of elapsed CFS periods.", "control_group"),
+		throttledPeriods: desc("cgroup_cpu_throttled_periods_total", "The total number of CFS periods the cgroup was throttled.", "control_group"),
+		throttledTime:    desc("cgroup_cpu_throttled_seconds_total", "The total time the cgroup was throttled.", "control_group"),
+		throttledRatio:   desc("cgroup_cpu_throttled_periods_ratio", "The ratio of elapsed CFS periods the cgroup was throttled.", "control_group"),
+	}
+}
+
+func (c *osCollector) Collect(o OS, ch chan<- prometheus.Metric) {
+	if o.Cgroup == nil {
+		return
+	}
+	if cpuacct := o.Cgroup.CPUAcct; cpuacct != nil {
+		ch <- prometheus.MustNewConstMetric(c.cpuacctUsage, prometheus.CounterValue, float64(cpuacct.UsageNanos)/1e9, cpuacct.ControlGroup)
+	}
+
+	cpu := o.Cgroup.CPU
+	group := cpu.ControlGroup
+	ch <- prometheus.MustNewConstMetric(c.cfsPeriod, prometheus.GaugeValue, float64(cpu.CfsPeriodMicros)/1e6, group)
+	if cpu.CfsQuotaMicros >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.cfsQuota, prometheus.GaugeValue, float64(cpu.CfsQuotaMicros)/1e6, group)
+	}
+	ch <- prometheus.MustNewConstMetric(c.elapsedPeriods, prometheus.CounterValue, float64(cpu.Stat.NumberOfElapsedPeriods), group)
+	ch <- prometheus.MustNewConstMetric(c.throttledPeriods, prometheus.CounterValue, float64(cpu.Stat.NumberOfTimesThrottled), group)
+	ch <- prometheus.MustNewConstMetric(c.throttledTime, prometheus.CounterValue, float64(cpu.Stat.TimeThrottledNanos)/1e9, group)
+	if cpu.Stat.NumberOfElapsedPeriods > 0 {
+		ratio := float64(cpu.Stat.NumberOfTimesThrottled) / float64(cpu.Stat.NumberOfElapsedPeriods)
+		ch <- prometheus.MustNewConstMetric(c.throttledRatio, prometheus.GaugeValue, ratio, group)
+	}
+}
@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collectOS returns the values written by the os collector for the stats in file, by desc.
+func collectOS(t *testing.T, c *osCollector, file string) map[*prometheus.Desc]float64 {
+	t.Helper()
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats NodeStats
+	if err := json.Unmarshal(content, &stats); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(stats.OS, ch)
+		close(ch)
+	}()
+	values := make(map[*prometheus.Desc]float64)
+	for m := range ch {
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Counter != nil {
+			values[m.Desc()] = out.Counter.GetValue()
+		} else {
+			values[m.Desc()] = out.Gauge.GetValue()
+		}
+	}
+	return values
+}
+
+func TestOSCollectCgroupV1(t *testing.T) {
+	c := newOSCollector()
+	got := collectOS(t, c, "testdata/node_stats.json")
+	want := map[*prometheus.Desc]float64{
+		c.cpuacctUsage:     7304.416115351,
+		c.cfsPeriod:        0.1,
+		c.cfsQuota:         0.1,
+		c.elapsedPeriods:   5875889,
+		c.throttledPeriods: 1219,
+		c.throttledTime:    124.716913549,
+		c.throttledRatio:   1219.0 / 5875889.0,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d metrics, want %d", len(got), len(want))
+	}
+	for desc, v := range want {
+		if got[desc] != v {
+			t.Errorf("%s = %v, want %v", desc, got[desc], v)
+		}
+	}
+}
+
+func TestOSCollectCgroupV2(t *testing.T) {
+	c := newOSCollector()
+	got := collectOS(t, c, "testdata/node_stats_cgroup_v2.json")
+	if got[c.cpuacctUsage] != 912.345678 || got[c.cfsPeriod] != 0.1 {
+		t.Errorf("usage = %v, period = %v", got[c.cpuacctUsage], got[c.cfsPeriod])
+	}
+	// The "max" quota is unlimited, and the ratio is undefined before any period elapsed.
+	if _, ok := got[c.cfsQuota]; ok {
+		t.Error("the unlimited quota is exported")
+	}
+	if _, ok := got[c.throttledRatio]; ok {
+		t.Error("the throttled ratio is exported without elapsed periods")
+	}
+	if _, ok := got[c.throttledPeriods]; !ok {
+		t.Error("the throttled periods are not exported")
+	}
+}
+
+func TestOSCollectWithoutCgroup(t *testing.T) {
+	c := newOSCollector()
+	if got := collectOS(t, c, "testdata/node.json"); len(got) != 0 {
+		t.Errorf("got %d metrics without cgroup, want none", len(got))
+	}
+}
+
+func TestOSCollectCgroupV2WithoutUsage(t *testing.T) {
+	var o OS
+	if err := json.Unmarshal([]byte(`{"cgroup":{"cpu":{"control_group":"/","cfs_period_micros":100000,"cfs_quota_micros":50000,"stat":{}}}}`), &o); err != nil {
+		t.Fatal(err)
+	}
+	c := newOSCollector()
+	ch := make(chan prometheus.Metric, 10)
+	c.Collect(o, ch)
+	close(ch)
+	for m := range ch {
+		if m.Desc() == c.cpuacctUsage {
+			t.Error("the usage is exported without cpuacct")
+		}
+	}
+}
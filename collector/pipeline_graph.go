@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxConditionLength is the max length of the condition label of if vertices.
+const maxConditionLength = 200
+
+type pipelineGraphCollector struct {
+	Vertex *prometheus.Desc
+	Edge   *prometheus.Desc
+}
+
+func newPipelineGraphCollector() *pipelineGraphCollector {
+	desc := newDescFunc(namespace, "pipeline_graph")
+	return &pipelineGraphCollector{
+		Vertex: desc("vertex_info", "A metric with a constant '1' value describing a vertex of the pipeline graph.",
+			"pipeline", "id", "type", "plugin_type", "name", "explicit_id", "condition",
+			"source", "line", "column", "parent_conditional", "parent_branch"),
+		Edge: desc("edge_info", "A metric with a constant '1' value describing an edge of the pipeline graph.",
+			"pipeline", "id", "from", "to", "type", "when"),
+	}
+}
+
+func (c *pipelineGraphCollector) Collect(p NodePipelines, ch chan<- prometheus.Metric) {
+	for pipelineName, pipeline := range p.Pipelines {
+		if pipeline.Graph == nil {
+			continue
+		}
+		graph := pipeline.Graph.Graph
+		parents := parentConditionals(graph)
+		for _, v := range graph.Vertices {
+			var source, line, column string
+			if v.Meta != nil {
+				source = v.Meta.Source.ID
+				line = strconv.Itoa(v.Meta.Source.Line)
+				column = strconv.Itoa(v.Meta.Source.Column)
+			}
+			parent := parents[v.ID]
+			ch <- prometheus.MustNewConstMetric(c.Vertex, prometheus.GaugeValue, 1.0,
+				pipelineName, v.ID, v.Type, v.PluginType, v.ConfigName, strconv.FormatBool(v.ExplicitID),
+				sanitizeLabelValue(v.Condition, maxConditionLength),
+				source, line, column, parent.id, parent.branch)
+		}
+		for _, e := range graph.Edges {
+			when := ""
+			if e.When != nil {
+				when = strconv.FormatBool(*e.When)
+			}
+			ch <- prometheus.MustNewConstMetric(c.Edge, prometheus.GaugeValue, 1.0, pipelineName, e.ID, e.From, e.To, e.Type, when)
+		}
+	}
+}
+
+type conditionalBranch struct {
+	id     string
+	branch string
+}
+
+// parentConditionals returns the innermost if vertex and branch each vertex is nested in.
+// A vertex is in a branch when it is only reachable through that branch of the if vertex,
+// so the vertices after the branches join again are not nested.
+func parentConditionals(g PipelineGraph) map[string]conditionalBranch {
+	next := make(map[string][]string)
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+	reachable := func(from []string) map[string]bool {
+		seen := make(map[string]bool)
+		stack := append([]string(nil), from...)
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			stack = append(stack, next[id]...)
+		}
+		return seen
+	}
+
+	parents := make(map[string]conditionalBranch)
+	// size of the branch of each parent, to keep the innermost one.
+	sizes := make(map[string]int)
+	for _, v := range g.Vertices {
+		if v.Type != "if" {
+			continue
+		}
+		targets := map[bool][]string{}
+		for _, e := range g.Edges {
+			if e.From == v.ID && e.When != nil {
+				targets[*e.When] = append(targets[*e.When], e.To)
+			}
+		}
+		reachTrue, reachFalse := reachable(targets[true]), reachable(targets[false])
+		for when, reach := range map[bool]map[string]bool{true: reachTrue, false: reachFalse} {
+			other := reachFalse
+			if !when {
+				other = reachTrue
+			}
+			branch := make([]string, 0, len(reach))
+			for id := range reach {
+				if !other[id] {
+					branch = append(branch, id)
+				}
+			}
+			for _, id := range branch {
+				if size, ok := sizes[id]; !ok || len(branch) < size {
+					sizes[id] = len(branch)
+					parents[id] = conditionalBranch{id: v.ID, branch: strconv.FormatBool(when)}
+				}
+			}
+		}
+	}
+	return parents
+}
@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func loadGraph(t *testing.T, pipeline string) PipelineGraph {
+	t.Helper()
+	content, err := ioutil.ReadFile("testdata/node_pipelines_graph.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pipelines NodePipelines
+	if err := json.Unmarshal(content, &pipelines); err != nil {
+		t.Fatal(err)
+	}
+	p, ok := pipelines.Pipelines[pipeline]
+	if !ok || p.Graph == nil {
+		t.Fatalf("no graph for pipeline %q", pipeline)
+	}
+	return p.Graph.Graph
+}
+
+func TestParentConditionals(t *testing.T) {
+	parents := parentConditionals(loadGraph(t, "main"))
+
+	tests := []struct {
+		vertex string
+		want   conditionalBranch
+	}{
+		// Before the outermost if.
+		{"beats_input", conditionalBranch{}},
+		{"__QUEUE__", conditionalBranch{}},
+		{"if_type", conditionalBranch{}},
+		// In the branches of the outer if.
+		{"grok_nginx", conditionalBranch{id: "if_type", branch: "true"}},
+		{"kv_other", conditionalBranch{id: "if_type", branch: "false"}},
+		// The nested if belongs to the outer branch, its vertices to the innermost one.
+		{"if_geo", conditionalBranch{id: "if_type", branch: "true"}},
+		{"geoip_client", conditionalBranch{id: "if_geo", branch: "true"}},
+		// After the join of both ifs, including the if without else.
+		{"date_timestamp", conditionalBranch{}},
+		{"es_output", conditionalBranch{}},
+	}
+	for _, tt := range tests {
+		if got := parents[tt.vertex]; got != tt.want {
+			t.Errorf("parentConditionals()[%q] = %+v, want %+v", tt.vertex, got, tt.want)
+		}
+	}
+}
+
+func TestParentConditionalsWithoutFalseEdge(t *testing.T) {
+	// An if at the end of the outputs has no false edge, so everything reachable
+	// through the true edge is in its branch.
+	when := true
+	g := PipelineGraph{
+		Vertices: []PipelineVertex{{ID: "if"}, {ID: "a"}, {ID: "b"}},
+		Edges: []PipelineEdge{
+			{From: "if", To: "a", When: &when},
+			{From: "a", To: "b"},
+		},
+	}
+	g.Vertices[0].Type = "if"
+	parents := parentConditionals(g)
+	for _, id := range []string{"a", "b"} {
+		if got, want := parents[id], (conditionalBranch{id: "if", branch: "true"}); got != want {
+			t.Errorf("parentConditionals()[%q] = %+v, want %+v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package collector
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type pipelineSettingsCollector struct {
+	Workers                *prometheus.Desc
+	BatchSize              *prometheus.Desc
+	BatchDelay             *prometheus.Desc
+	ConfigReloadAutomatic  *prometheus.Desc
+	DeadLetterQueueEnabled *prometheus.Desc
+	Info                   *prometheus.Desc
+}
+
+func newPipelineSettingsCollector() *pipelineSettingsCollector {
+	desc := newDescFunc(namespace, "pipeline_settings")
+	return &pipelineSettingsCollector{
+		Workers:                desc("workers", "The number of workers of the pipeline.", "pipeline"),
+		BatchSize:              desc("batch_size", "The maximum number of events a worker of the pipeline collects before executing filters and outputs.", "pipeline"),
+		BatchDelay:             desc("batch_delay_seconds", "How long the pipeline waits before dispatching an undersized batch to workers.", "pipeline"),
+		ConfigReloadAutomatic:  desc("config_reload_automatic", "Whether the pipeline config is reloaded automatically.", "pipeline"),
+		DeadLetterQueueEnabled: desc("dead_letter_queue_enabled", "Whether the dead letter queue of the pipeline is enabled.", "pipeline"),
+		Info:                   desc("info", "A metric with a constant '1' value labeled by the ordered and ecs_compatibility settings of the pipeline.", "pipeline", "ordered", "ecs_compatibility"),
+	}
+}
+
+func (c *pipelineSettingsCollector) Collect(p NodePipelines, ch chan<- prometheus.Metric) {
+	for pipelineName, s := range p.Pipelines {
+		ch <- prometheus.MustNewConstMetric(c.Workers, prometheus.GaugeValue, float64(s.Workers), pipelineName)
+		ch <- prometheus.MustNewConstMetric(c.BatchSize, prometheus.GaugeValue, float64(s.BatchSize), pipelineName)
+		ch <- prometheus.MustNewConstMetric(c.BatchDelay, prometheus.GaugeValue, float64(s.BatchDelay)/1000.0, pipelineName)
+		ch <- prometheus.MustNewConstMetric(c.ConfigReloadAutomatic, prometheus.GaugeValue, boolToFloat64(s.ConfigReloadAutomatic), pipelineName)
+		ch <- prometheus.MustNewConstMetric(c.DeadLetterQueueEnabled, prometheus.GaugeValue, boolToFloat64(s.DeadLetterQueueEnabled), pipelineName)
+		ch <- prometheus.MustNewConstMetric(c.Info, prometheus.GaugeValue, 1.0, pipelineName, string(s.Ordered), string(s.ECSCompatibility))
+	}
+}
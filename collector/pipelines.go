@@ -1,12 +1,54 @@
 package collector
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// PluginTypes lists the types of plugins in a pipeline.
+var PluginTypes = []string{"input", "filter", "output", "codec"}
+
+// pluginFilter selects the plugins whose series are exported.
+type pluginFilter struct {
+	// types is nil when all types are selected.
+	types            map[string]bool
+	include, exclude *regexp.Regexp
+}
+
+func newPluginFilter(types []string, include, exclude *regexp.Regexp) (pluginFilter, error) {
+	f := pluginFilter{include: include, exclude: exclude}
+	if len(types) > 0 {
+		f.types = make(map[string]bool, len(types))
+	}
+	for _, t := range types {
+		known := false
+		for _, pluginType := range PluginTypes {
+			known = known || t == pluginType
+		}
+		if !known {
+			return f, fmt.Errorf("unknown plugin type %q", t)
+		}
+		f.types[t] = true
+	}
+	return f, nil
+}
+
+func (f pluginFilter) selected(pluginType, id, name string) bool {
+	if f.types != nil && !f.types[pluginType] {
+		return false
+	}
+	if f.include != nil && !f.include.MatchString(name) && !f.include.MatchString(id) {
+		return false
+	}
+	return f.exclude == nil || !(f.exclude.MatchString(name) || f.exclude.MatchString(id))
+}
+
 type pipelinesCollector struct {
+	plugins pluginFilter
+
 	// Event
 	In                *prometheus.Desc
 	Filtered          *prometheus.Desc
@@ -14,66 +56,163 @@ type pipelinesCollector struct {
 	Duration          *prometheus.Desc
 	QueuePushDuration *prometheus.Desc
 
+	// Flow
+	Flow *flowCollector
+
+	// Reloads
+	ReloadsSuccesses            *prometheus.Desc
+	ReloadsFailures             *prometheus.Desc
+	ReloadsLastSuccessTimestamp *prometheus.Desc
+	ReloadsLastFailureTimestamp *prometheus.Desc
+	ReloadsLastError            *prometheus.Desc
+
 	// Input Plugins
 	InputConnections       *prometheus.Desc
 	InputQueuePushDuration *prometheus.Desc
 	InputOut               *prometheus.Desc
+	InputFlow              *flowCollector
 
 	// Filter Plugins
 	FilterDuration *prometheus.Desc
 	FilterIn       *prometheus.Desc
 	FilterOut      *prometheus.Desc
+	FilterMatches  *prometheus.Desc
+	FilterFailures *prometheus.Desc
+	FilterFlow     *flowCollector
 
 	// Output Plugins
 	OutputDuration *prometheus.Desc
 	OutputIn       *prometheus.Desc
 	OutputOut      *prometheus.Desc
+	OutputFlow     *flowCollector
+
+	// Elasticsearch Output Plugins
+	OutputDocumentsSuccesses            *prometheus.Desc
+	OutputDocumentsNonRetryableFailures *prometheus.Desc
+	OutputDocumentsDlqRouted            *prometheus.Desc
+	OutputBulkRequestsSuccesses         *prometheus.Desc
+	OutputBulkRequestsWithErrors        *prometheus.Desc
+	OutputBulkRequestsFailures          *prometheus.Desc
+	OutputBulkResponses                 *prometheus.Desc
+
+	// Codec Plugins
+	CodecDecodeWritesIn *prometheus.Desc
+	CodecDecodeOut      *prometheus.Desc
+	CodecDecodeDuration *prometheus.Desc
+	CodecEncodeWritesIn *prometheus.Desc
+	CodecEncodeDuration *prometheus.Desc
 
 	// Queue
 	EventsCount  *prometheus.Desc
 	QueueSize    *prometheus.Desc
 	MaxQueueSize *prometheus.Desc
+
+	// Persisted Queue
+	PageCapacity    *prometheus.Desc
+	MaxUnreadEvents *prometheus.Desc
+	FreeSpace       *prometheus.Desc
+
+	// Dead Letter Queue
+	DLQSize          *prometheus.Desc
+	DLQMaxSize       *prometheus.Desc
+	DLQDroppedEvents *prometheus.Desc
+	DLQExpiredEvents *prometheus.Desc
+	DLQLastError     *prometheus.Desc
 }
 
-func newPipelinesCollector() *pipelinesCollector {
+func newPipelinesCollector(plugins pluginFilter) *pipelinesCollector {
 	desc := newDescFunc(namespace, "pipeline")
 	return &pipelinesCollector{
+		plugins: plugins,
+
 		In:                desc("event_in_total", "The total number of events in.", "pipeline"),
 		Filtered:          desc("event_filtered_total", "The total numbers of filtered.", "pipeline"),
 		Out:               desc("event_out_total", "The total number of events out.", "pipeline"),
 		Duration:          desc("event_duration_seconds_total", "The total process duration time in seconds.", "pipeline"),
 		QueuePushDuration: desc("event_queue_push_duration_seconds_total", "The total in queue duration time in seconds.", "pipeline"),
 
+		Flow: newFlowCollector("pipeline_flow", flowHelps, "pipeline"),
+
+		ReloadsSuccesses:            desc("reloads_successes_total", "Number of successful pipeline reloads.", "pipeline"),
+		ReloadsFailures:             desc("reloads_failures_total", "Number of failures during pipeline reload.", "pipeline"),
+		ReloadsLastSuccessTimestamp: desc("reloads_last_success_timestamp_seconds", "Unix time of the last successful pipeline reload.", "pipeline"),
+		ReloadsLastFailureTimestamp: desc("reloads_last_failure_timestamp_seconds", "Unix time of the last failed pipeline reload.", "pipeline"),
+		ReloadsLastError:            desc("reloads_last_error_info", "A metric with a constant '1' value labeled by the truncated message of the last pipeline reload error.", "pipeline", "message"),
+
 		InputConnections:       desc("input_connections", "The current number of connections.", "pipeline", "id", "name"),
 		InputQueuePushDuration: desc("input_queue_push_seconds_total", "The total in queue duration time in seconds", "pipeline", "id", "name"),
 		InputOut:               desc("input_out_total", "The total number of events out.", "pipeline", "id", "name"),
+		InputFlow:              newFlowCollector("pipeline_input_flow", inputFlowHelps, "pipeline", "id", "name"),
 
 		FilterDuration: desc("filter_duration_seconds_total", "The total process duration time in seconds", "pipeline", "id", "name", "index"),
 		FilterIn:       desc("filter_in_total", "The total number of events in.", "pipeline", "id", "name", "index"),
 		FilterOut:      desc("filter_out_total", "The total number of events out.", "pipeline", "id", "name", "index"),
+		FilterMatches:  desc("filter_matches_total", "The total number of events matched.", "pipeline", "id", "name", "index"),
+		FilterFailures: desc("filter_failures_total", "The total number of events failed to match.", "pipeline", "id", "name", "index"),
+		FilterFlow:     newFlowCollector("pipeline_filter_flow", workerFlowHelps, "pipeline", "id", "name", "index"),
 
 		OutputDuration: desc("output_duration_seconds_total", "The total process duration time in seconds", "pipeline", "id", "name"),
 		OutputIn:       desc("output_in_total", "The total number of events in.", "pipeline", "id", "name"),
 		OutputOut:      desc("output_out_total", "The total number of events out.", "pipeline", "id", "name"),
+		OutputFlow:     newFlowCollector("pipeline_output_flow", workerFlowHelps, "pipeline", "id", "name"),
+
+		OutputDocumentsSuccesses:            desc("output_documents_successes_total", "The total number of documents indexed successfully.", "pipeline", "id", "name"),
+		OutputDocumentsNonRetryableFailures: desc("output_documents_non_retryable_failures_total", "The total number of documents failed without retry.", "pipeline", "id", "name"),
+		OutputDocumentsDlqRouted:            desc("output_documents_dlq_routed_total", "The total number of documents sent to the dead letter queue.", "pipeline", "id", "name"),
+		OutputBulkRequestsSuccesses:         desc("output_bulk_requests_successes_total", "The total number of successful bulk requests.", "pipeline", "id", "name"),
+		OutputBulkRequestsWithErrors:        desc("output_bulk_requests_with_errors_total", "The total number of bulk requests with some failed documents.", "pipeline", "id", "name"),
+		OutputBulkRequestsFailures:          desc("output_bulk_requests_failures_total", "The total number of failed bulk requests.", "pipeline", "id", "name"),
+		OutputBulkResponses:                 desc("output_bulk_responses_total", "The total number of bulk responses by HTTP status code.", "pipeline", "id", "name", "status"),
+
+		CodecDecodeWritesIn: desc("codec_decode_writes_in_total", "The total number of writes to decode.", "pipeline", "id", "name"),
+		CodecDecodeOut:      desc("codec_decode_out_total", "The total number of decoded events out.", "pipeline", "id", "name"),
+		CodecDecodeDuration: desc("codec_decode_duration_seconds_total", "The total decode duration time in seconds.", "pipeline", "id", "name"),
+		CodecEncodeWritesIn: desc("codec_encode_writes_in_total", "The total number of writes to encode.", "pipeline", "id", "name"),
+		CodecEncodeDuration: desc("codec_encode_duration_seconds_total", "The total encode duration time in seconds.", "pipeline", "id", "name"),
 
 		EventsCount:  desc("queue_event_count", "The current events in queue.", "pipeline", "queue_type"),
 		QueueSize:    desc("queue_size_bytes", "The current queue size in bytes.", "pipeline", "queue_type"),
 		MaxQueueSize: desc("queue_max_size_bytes", "The max queue size in bytes.", "pipeline", "queue_type"),
+
+		PageCapacity:    desc("queue_page_capacity_bytes", "The size of a persisted queue page in bytes.", "pipeline", "queue_type"),
+		MaxUnreadEvents: desc("queue_max_unread_events", "The max number of unread events in the persisted queue.", "pipeline", "queue_type"),
+		FreeSpace:       desc("queue_data_free_space_bytes", "The free space on the volume of the persisted queue in bytes.", "pipeline", "queue_type", "path", "storage_type"),
+
+		DLQSize:          desc("dead_letter_queue_size_bytes", "The current dead letter queue size in bytes.", "pipeline"),
+		DLQMaxSize:       desc("dead_letter_queue_max_size_bytes", "The max dead letter queue size in bytes.", "pipeline", "storage_policy"),
+		DLQDroppedEvents: desc("dead_letter_queue_dropped_events_total", "The total number of events dropped because the dead letter queue was full.", "pipeline"),
+		DLQExpiredEvents: desc("dead_letter_queue_expired_events_total", "The total number of events removed from the dead letter queue by age.", "pipeline"),
+		DLQLastError:     desc("dead_letter_queue_last_error_info", "A metric with a constant '1' value labeled by the truncated message of the last dead letter queue error.", "pipeline", "message"),
 	}
 }
 
 func (c *pipelinesCollector) Collect(p map[string]Pipeline, ch chan<- prometheus.Metric) {
 	for pipelineName, pipeline := range p {
 		c.collectEvent(pipelineName, pipeline, ch)
+		c.Flow.Collect(pipeline.Flow, ch, pipelineName)
 		c.collectQueue(pipelineName, pipeline, ch)
+		c.collectReloads(pipelineName, pipeline, ch)
+		c.collectDeadLetterQueue(pipelineName, pipeline, ch)
 		for _, plugin := range pipeline.Plugins.Inputs {
-			c.collectInput(pipelineName, plugin, ch)
+			if c.plugins.selected("input", plugin.ID, plugin.Name) {
+				c.collectInput(pipelineName, plugin, ch)
+			}
 		}
+		// The index label keeps the position among all filters, so skipping some doesn't renumber the others.
 		for idx, plugin := range pipeline.Plugins.Filters {
-			c.collectFilter(pipelineName, idx, plugin, ch)
+			if c.plugins.selected("filter", plugin.ID, plugin.Name) {
+				c.collectFilter(pipelineName, idx, plugin, ch)
+			}
 		}
 		for _, plugin := range pipeline.Plugins.Outputs {
-			c.collectOutput(pipelineName, plugin, ch)
+			if c.plugins.selected("output", plugin.ID, plugin.Name) {
+				c.collectOutput(pipelineName, plugin, ch)
+			}
+		}
+		for _, plugin := range pipeline.Plugins.Codecs {
+			if c.plugins.selected("codec", plugin.ID, plugin.Name) {
+				c.collectCodec(pipelineName, plugin, ch)
+			}
 		}
 	}
 }
@@ -97,6 +236,54 @@ func (c *pipelinesCollector) collectQueue(pipelineName string, p Pipeline, ch ch
 	ch <- prometheus.MustNewConstMetric(c.EventsCount, prometheus.GaugeValue, float64(p.Queue.EventsCount), pipelineName, queueType)
 	ch <- prometheus.MustNewConstMetric(c.QueueSize, prometheus.CounterValue, float64(p.Queue.QueueSizeInBytes), pipelineName, queueType)
 	ch <- prometheus.MustNewConstMetric(c.MaxQueueSize, prometheus.CounterValue, float64(p.Queue.MaxQueueSizeInBytes), pipelineName, queueType)
+
+	if capacity := p.Queue.Capacity; capacity != nil {
+		ch <- prometheus.MustNewConstMetric(c.PageCapacity, prometheus.GaugeValue, float64(capacity.PageCapacityInBytes), pipelineName, queueType)
+		ch <- prometheus.MustNewConstMetric(c.MaxUnreadEvents, prometheus.GaugeValue, float64(capacity.MaxUnreadEvents), pipelineName, queueType)
+	}
+	if data := p.Queue.Data; data != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.FreeSpace, prometheus.GaugeValue, float64(data.FreeSpaceInBytes), pipelineName, queueType, data.Path, data.StorageType)
+	}
+}
+
+// dlqNoError is the last_error of a dead letter queue which has never failed.
+const dlqNoError = "no errors"
+
+func (c *pipelinesCollector) collectDeadLetterQueue(pipelineName string, p Pipeline, ch chan<- prometheus.Metric) {
+	dlq := p.DeadLetterQueue
+	if dlq == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.DLQSize, prometheus.GaugeValue, float64(dlq.QueueSizeInBytes), pipelineName)
+	ch <- prometheus.MustNewConstMetric(c.DLQMaxSize, prometheus.GaugeValue, float64(dlq.MaxQueueSizeInBytes), pipelineName, dlq.StoragePolicy)
+	ch <- prometheus.MustNewConstMetric(c.DLQDroppedEvents, prometheus.CounterValue, float64(dlq.DroppedEvents), pipelineName)
+	ch <- prometheus.MustNewConstMetric(c.DLQExpiredEvents, prometheus.CounterValue, float64(dlq.ExpiredEvents), pipelineName)
+	if dlq.LastError != "" && dlq.LastError != dlqNoError {
+		ch <- prometheus.MustNewConstMetric(c.DLQLastError, prometheus.GaugeValue, 1.0,
+			pipelineName, sanitizeLabelValue(dlq.LastError, maxErrorMessageLength))
+	}
+}
+
+// maxErrorMessageLength limits the length of error messages exported as label values.
+const maxErrorMessageLength = 200
+
+func (c *pipelinesCollector) collectReloads(pipelineName string, p Pipeline, ch chan<- prometheus.Metric) {
+	r := p.Reloads
+	ch <- prometheus.MustNewConstMetric(c.ReloadsSuccesses, prometheus.CounterValue, float64(r.Successes), pipelineName)
+	ch <- prometheus.MustNewConstMetric(c.ReloadsFailures, prometheus.CounterValue, float64(r.Failures), pipelineName)
+	if r.LastSuccessTimestamp != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.ReloadsLastSuccessTimestamp, prometheus.GaugeValue, float64(r.LastSuccessTimestamp.UnixNano())/1e9, pipelineName)
+	}
+	if r.LastFailureTimestamp != nil {
+		ch <- prometheus.MustNewConstMetric(
+			c.ReloadsLastFailureTimestamp, prometheus.GaugeValue, float64(r.LastFailureTimestamp.UnixNano())/1e9, pipelineName)
+	}
+	if r.LastError != nil {
+		ch <- prometheus.MustNewConstMetric(c.ReloadsLastError, prometheus.GaugeValue, 1.0,
+			pipelineName, sanitizeLabelValue(r.LastError.Message, maxErrorMessageLength))
+	}
 }
 
 func (c *pipelinesCollector) collectInput(pipelineName string, p InputPlugin, ch chan<- prometheus.Metric) {
@@ -104,6 +291,7 @@ func (c *pipelinesCollector) collectInput(pipelineName string, p InputPlugin, ch
 	ch <- prometheus.MustNewConstMetric(
 		c.InputQueuePushDuration, prometheus.CounterValue, float64(p.Events.QueuePushDurationInMillis)/1000.0, pipelineName, p.ID, p.Name)
 	ch <- prometheus.MustNewConstMetric(c.InputOut, prometheus.CounterValue, float64(p.Events.Out), pipelineName, p.ID, p.Name)
+	c.InputFlow.Collect(p.Flow, ch, pipelineName, p.ID, p.Name)
 }
 
 func (c *pipelinesCollector) collectFilter(pipelineName string, index int, p FilterPlugin, ch chan<- prometheus.Metric) {
@@ -112,6 +300,13 @@ func (c *pipelinesCollector) collectFilter(pipelineName string, index int, p Fil
 		c.FilterDuration, prometheus.CounterValue, float64(p.Events.DurationInMillis)/1000.0, pipelineName, p.ID, p.Name, idx)
 	ch <- prometheus.MustNewConstMetric(c.FilterIn, prometheus.CounterValue, float64(p.Events.In), pipelineName, p.ID, p.Name, idx)
 	ch <- prometheus.MustNewConstMetric(c.FilterOut, prometheus.CounterValue, float64(p.Events.Out), pipelineName, p.ID, p.Name, idx)
+	if p.Matches != nil {
+		ch <- prometheus.MustNewConstMetric(c.FilterMatches, prometheus.CounterValue, float64(*p.Matches), pipelineName, p.ID, p.Name, idx)
+	}
+	if p.Failures != nil {
+		ch <- prometheus.MustNewConstMetric(c.FilterFailures, prometheus.CounterValue, float64(*p.Failures), pipelineName, p.ID, p.Name, idx)
+	}
+	c.FilterFlow.Collect(p.Flow, ch, pipelineName, p.ID, p.Name, idx)
 }
 
 func (c *pipelinesCollector) collectOutput(pipelineName string, p OutputPlugin, ch chan<- prometheus.Metric) {
@@ -119,4 +314,30 @@ func (c *pipelinesCollector) collectOutput(pipelineName string, p OutputPlugin,
 		c.OutputDuration, prometheus.CounterValue, float64(p.Events.DurationInMillis)/1000.0, pipelineName, p.ID, p.Name)
 	ch <- prometheus.MustNewConstMetric(c.OutputIn, prometheus.CounterValue, float64(p.Events.In), pipelineName, p.ID, p.Name)
 	ch <- prometheus.MustNewConstMetric(c.OutputOut, prometheus.CounterValue, float64(p.Events.Out), pipelineName, p.ID, p.Name)
+	c.OutputFlow.Collect(p.Flow, ch, pipelineName, p.ID, p.Name)
+
+	if d := p.Documents; d != nil {
+		ch <- prometheus.MustNewConstMetric(c.OutputDocumentsSuccesses, prometheus.CounterValue, float64(d.Successes), pipelineName, p.ID, p.Name)
+		ch <- prometheus.MustNewConstMetric(
+			c.OutputDocumentsNonRetryableFailures, prometheus.CounterValue, float64(d.NonRetryableFailures), pipelineName, p.ID, p.Name)
+		ch <- prometheus.MustNewConstMetric(c.OutputDocumentsDlqRouted, prometheus.CounterValue, float64(d.DlqRouted), pipelineName, p.ID, p.Name)
+	}
+	if b := p.BulkRequests; b != nil {
+		ch <- prometheus.MustNewConstMetric(c.OutputBulkRequestsSuccesses, prometheus.CounterValue, float64(b.Successes), pipelineName, p.ID, p.Name)
+		ch <- prometheus.MustNewConstMetric(c.OutputBulkRequestsWithErrors, prometheus.CounterValue, float64(b.WithErrors), pipelineName, p.ID, p.Name)
+		ch <- prometheus.MustNewConstMetric(c.OutputBulkRequestsFailures, prometheus.CounterValue, float64(b.Failures), pipelineName, p.ID, p.Name)
+		for status, count := range b.Responses {
+			ch <- prometheus.MustNewConstMetric(c.OutputBulkResponses, prometheus.CounterValue, float64(count), pipelineName, p.ID, p.Name, status)
+		}
+	}
+}
+
+func (c *pipelinesCollector) collectCodec(pipelineName string, p CodecPlugin, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.CodecDecodeWritesIn, prometheus.CounterValue, float64(p.Decode.WritesIn), pipelineName, p.ID, p.Name)
+	ch <- prometheus.MustNewConstMetric(c.CodecDecodeOut, prometheus.CounterValue, float64(p.Decode.Out), pipelineName, p.ID, p.Name)
+	ch <- prometheus.MustNewConstMetric(
+		c.CodecDecodeDuration, prometheus.CounterValue, float64(p.Decode.DurationInMillis)/1000.0, pipelineName, p.ID, p.Name)
+	ch <- prometheus.MustNewConstMetric(c.CodecEncodeWritesIn, prometheus.CounterValue, float64(p.Encode.WritesIn), pipelineName, p.ID, p.Name)
+	ch <- prometheus.MustNewConstMetric(
+		c.CodecEncodeDuration, prometheus.CounterValue, float64(p.Encode.DurationInMillis)/1000.0, pipelineName, p.ID, p.Name)
 }
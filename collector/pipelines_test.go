@@ -0,0 +1,170 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectPipelines returns the values of the pipelines collector for the /_node/stats fixture.
+func collectPipelines(t *testing.T, plugins pluginFilter) map[string]float64 {
+	t.Helper()
+	stats := loadStats(t)
+	c := newPipelinesCollector(plugins)
+	return metricValues(t, func(ch chan<- prometheus.Metric) { c.Collect(stats.Pipelines, ch) })
+}
+
+// assertValues checks the wanted values, where -1 means the series must not be exported.
+func assertValues(t *testing.T, got, want map[string]float64) {
+	t.Helper()
+	for key, v := range want {
+		value, ok := got[key]
+		switch {
+		case v < 0 && ok:
+			t.Errorf("%s = %v, want it not exported", key, value)
+		case v >= 0 && !ok:
+			t.Errorf("%s is not exported, want %v", key, v)
+		case v >= 0 && value != v:
+			t.Errorf("%s = %v, want %v", key, value, v)
+		}
+	}
+}
+
+func TestPipelinesCodec(t *testing.T) {
+	const codec = `id="json_9562e6c4-7a1a-4c18-919f-f012e58923dd",name="json",pipeline="pipeline-1"`
+	assertValues(t, collectPipelines(t, pluginFilter{}), map[string]float64{
+		`logstash_pipeline_codec_decode_writes_in_total{` + codec + `}`:        567639,
+		`logstash_pipeline_codec_decode_out_total{` + codec + `}`:              567639,
+		`logstash_pipeline_codec_decode_duration_seconds_total{` + codec + `}`: 86.778,
+		`logstash_pipeline_codec_encode_writes_in_total{` + codec + `}`:        0,
+		`logstash_pipeline_codec_encode_duration_seconds_total{` + codec + `}`: 0,
+	})
+}
+
+func TestPipelinesElasticsearchOutput(t *testing.T) {
+	const output = `id="0f72afb28c5ff3a3897d87b04fc1b0a5fe8358cb55bbc29b995056fd868e612b",name="elasticsearch",pipeline="pipeline-1"`
+	assertValues(t, collectPipelines(t, pluginFilter{}), map[string]float64{
+		`logstash_pipeline_output_documents_successes_total{` + output + `}`:              567639,
+		`logstash_pipeline_output_documents_non_retryable_failures_total{` + output + `}`: 4,
+		`logstash_pipeline_output_documents_dlq_routed_total{` + output + `}`:             2,
+		`logstash_pipeline_output_bulk_requests_successes_total{` + output + `}`:          50735,
+		`logstash_pipeline_output_bulk_requests_with_errors_total{` + output + `}`:        6,
+		`logstash_pipeline_output_bulk_requests_failures_total{` + output + `}`:           1,
+		`logstash_pipeline_output_bulk_responses_total{` + output + `,status="200"}`:      50735,
+		`logstash_pipeline_output_bulk_responses_total{` + output + `,status="429"}`:      17,
+	})
+}
+
+func TestPipelinesFilter(t *testing.T) {
+	assertValues(t, collectPipelines(t, pluginFilter{}), map[string]float64{
+		`logstash_pipeline_filter_matches_total{id="parse timestamp",index="6",name="date",pipeline="pipeline-1"}`:  326900,
+		`logstash_pipeline_filter_failures_total{id="parse timestamp",index="6",name="date",pipeline="pipeline-1"}`: 1,
+		`logstash_pipeline_filter_in_total{id="parse timestamp",index="6",name="date",pipeline="pipeline-1"}`:       326901,
+		// Filters without matches and failures don't export them.
+		`logstash_pipeline_filter_matches_total{id="parse JSON",index="2",name="json",pipeline="pipeline-1"}`:  -1,
+		`logstash_pipeline_filter_failures_total{id="parse JSON",index="2",name="json",pipeline="pipeline-1"}`: -1,
+	})
+}
+
+func TestPipelinesQueue(t *testing.T) {
+	const queue = `pipeline="pipeline-1",queue_type="persisted"`
+	const freeSpace = `logstash_pipeline_queue_data_free_space_bytes{path="/usr/share/logstash/data/queue/main",` + queue + `,storage_type="ext4"}`
+	assertValues(t, collectPipelines(t, pluginFilter{}), map[string]float64{
+		`logstash_pipeline_queue_event_count{` + queue + `}`:         0,
+		`logstash_pipeline_queue_size_bytes{` + queue + `}`:          45085456,
+		`logstash_pipeline_queue_max_size_bytes{` + queue + `}`:      1073741824,
+		`logstash_pipeline_queue_page_capacity_bytes{` + queue + `}`: 67108864,
+		`logstash_pipeline_queue_max_unread_events{` + queue + `}`:   0,
+		freeSpace: 12000000000,
+	})
+}
+
+func TestPipelinesDeadLetterQueue(t *testing.T) {
+	got := collectPipelines(t, pluginFilter{})
+	assertValues(t, got, map[string]float64{
+		`logstash_pipeline_dead_letter_queue_size_bytes{pipeline="pipeline-1"}`:                                 1,
+		`logstash_pipeline_dead_letter_queue_max_size_bytes{pipeline="pipeline-1",storage_policy="drop_newer"}`: 1073741824,
+		`logstash_pipeline_dead_letter_queue_dropped_events_total{pipeline="pipeline-1"}`:                       3,
+		`logstash_pipeline_dead_letter_queue_expired_events_total{pipeline="pipeline-1"}`:                       0,
+	})
+	// The "no errors" last_error of the fixture is not an error.
+	for key := range got {
+		if strings.HasPrefix(key, "logstash_pipeline_dead_letter_queue_last_error_info") {
+			t.Errorf("%s is exported for %q", key, dlqNoError)
+		}
+	}
+
+	stats := loadStats(t)
+	p := stats.Pipelines["pipeline-1"]
+	p.DeadLetterQueue.LastError = "Cannot write event to DLQ(path: /data/dead_letter_queue/main): reached maxQueueSize of 1073741824"
+	c := newPipelinesCollector(pluginFilter{})
+	got = metricValues(t, func(ch chan<- prometheus.Metric) { c.Collect(map[string]Pipeline{"pipeline-1": p}, ch) })
+	assertValues(t, got, map[string]float64{
+		`logstash_pipeline_dead_letter_queue_last_error_info{message="` + p.DeadLetterQueue.LastError + `",pipeline="pipeline-1"}`: 1,
+	})
+}
+
+func TestPluginFilter(t *testing.T) {
+	// Excluding mutate keeps the index of the other filters.
+	exclude, err := newPluginFilter(nil, nil, regexp.MustCompile(`^(?:mutate)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := collectPipelines(t, exclude)
+	assertValues(t, got, map[string]float64{
+		`logstash_pipeline_filter_in_total{id="parse JSON",index="2",name="json",pipeline="pipeline-1"}`:                0,
+		`logstash_pipeline_filter_in_total{id="parse LTSV",index="3",name="kv",pipeline="pipeline-1"}`:                  0,
+		`logstash_pipeline_filter_in_total{id="assign document_id",index="4",name="fingerprint",pipeline="pipeline-1"}`: 567639,
+		`logstash_pipeline_filter_in_total{id="parse timestamp",index="6",name="date",pipeline="pipeline-1"}`:           326901,
+	})
+	for key := range got {
+		if strings.Contains(key, `name="mutate"`) {
+			t.Errorf("%s is exported, want mutate excluded", key)
+		}
+	}
+
+	// Selecting only filters drops the codecs, inputs and outputs.
+	types, err := newPluginFilter([]string{"filter"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = collectPipelines(t, types)
+	for key := range got {
+		for _, prefix := range []string{"logstash_pipeline_codec_", "logstash_pipeline_input_", "logstash_pipeline_output_"} {
+			if strings.HasPrefix(key, prefix) {
+				t.Errorf("%s is exported, want only filters", key)
+			}
+		}
+	}
+	if _, ok := got[`logstash_pipeline_filter_in_total{id="set default timezone",index="0",name="mutate",pipeline="pipeline-1"}`]; !ok {
+		t.Error("the filters are not exported")
+	}
+
+	if _, err := newPluginFilter([]string{"filters"}, nil, nil); err == nil {
+		t.Error("newPluginFilter() accepted the unknown plugin type")
+	}
+}
+
+func TestPluginFilterSelected(t *testing.T) {
+	f, err := newPluginFilter([]string{"filter", "output"}, regexp.MustCompile(`^(?:grok|mutate|es-.*)$`), regexp.MustCompile(`^(?:mutate)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pluginType, id, name string
+		want                 bool
+	}{
+		{"filter", "parse", "grok", true},
+		{"output", "es-main", "elasticsearch", true},
+		{"filter", "tag", "mutate", false},
+		{"filter", "parse", "kv", false},
+		{"codec", "es-codec", "json", false},
+	}
+	for _, tt := range tests {
+		if got := f.selected(tt.pluginType, tt.id, tt.name); got != tt.want {
+			t.Errorf("selected(%q, %q, %q) = %v, want %v", tt.pluginType, tt.id, tt.name, got, tt.want)
+		}
+	}
+}
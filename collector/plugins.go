@@ -0,0 +1,32 @@
+package collector
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type pluginsCollector struct {
+	info            *prometheus.Desc
+	versionMismatch *prometheus.Desc
+
+	// manifest maps the plugin names to their expected versions.
+	manifest map[string]string
+}
+
+func newPluginsCollector(manifest map[string]string) *pluginsCollector {
+	desc := newDescFunc(namespace, "plugin")
+	return &pluginsCollector{
+		info:            desc("info", "A metric with a constant '1' value labeled by name and version of the installed plugin.", "name", "version"),
+		versionMismatch: desc("version_mismatch", "Whether the installed version of the plugin differs from the manifest. The version is empty when the plugin is missing.", "name", "expected_version", "version"),
+		manifest:        manifest,
+	}
+}
+
+func (c *pluginsCollector) Collect(p NodePlugins, ch chan<- prometheus.Metric) {
+	installed := make(map[string]string, len(p.Plugins))
+	for _, plugin := range p.Plugins {
+		installed[plugin.Name] = plugin.Version
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1.0, plugin.Name, plugin.Version)
+	}
+	for name, expected := range c.manifest {
+		version := installed[name]
+		ch <- prometheus.MustNewConstMetric(c.versionMismatch, prometheus.GaugeValue, boolToFloat64(version != expected), name, expected, version)
+	}
+}
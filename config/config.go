@@ -0,0 +1,349 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Wing924/logstash-exporter/collector"
+
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DefaultListenAddress = ":9649"
+	DefaultTelemetryPath = "/metrics"
+	DefaultScrapeURI     = "http://localhost:9600"
+	DefaultTimeout       = 5 * time.Second
+
+	// ProbePath serves the metrics of the logstash given by the target parameter.
+	ProbePath = "/probe"
+
+	// TargetLabel distinguishes the metrics of each logstash when more than one scrape URI is configured.
+	TargetLabel = "target"
+)
+
+type Config struct {
+	Web      WebConfig         `yaml:"web"`
+	Logstash LogstashConfig    `yaml:"logstash"`
+	Probe    ProbeConfig       `yaml:"probe"`
+	Labels   map[string]string `yaml:"labels"`
+	// Collectors enables or disables the sub-collectors by name.
+	Collectors map[string]bool `yaml:"collectors"`
+	Plugins    PluginsConfig   `yaml:"plugins"`
+	Pipelines  PipelinesConfig `yaml:"pipelines"`
+}
+
+type PipelinesConfig struct {
+	// Include and Exclude are regular expressions matching the whole pipeline id.
+	// A pipeline is exported if it matches Include, when given, and doesn't match Exclude.
+	Include string `yaml:"include"`
+	Exclude string `yaml:"exclude"`
+
+	// PluginTypes are the plugin types exported among input, filter, output and codec. All are exported if empty.
+	PluginTypes []string `yaml:"plugin_types"`
+	// PluginInclude and PluginExclude are regular expressions matching the whole plugin name or id.
+	PluginInclude string `yaml:"plugin_include"`
+	PluginExclude string `yaml:"plugin_exclude"`
+}
+
+// Regexps compiles Include and Exclude, anchored to match the whole pipeline id.
+// They are nil when not given.
+func (c *PipelinesConfig) Regexps() (include, exclude *regexp.Regexp, err error) {
+	if include, err = compileAnchored(c.Include); err != nil {
+		return nil, nil, fmt.Errorf("pipelines.include: %w", err)
+	}
+	if exclude, err = compileAnchored(c.Exclude); err != nil {
+		return nil, nil, fmt.Errorf("pipelines.exclude: %w", err)
+	}
+	return include, exclude, nil
+}
+
+// PluginRegexps compiles PluginInclude and PluginExclude, anchored to match the whole plugin name or id.
+// They are nil when not given.
+func (c *PipelinesConfig) PluginRegexps() (include, exclude *regexp.Regexp, err error) {
+	if include, err = compileAnchored(c.PluginInclude); err != nil {
+		return nil, nil, fmt.Errorf("pipelines.plugin_include: %w", err)
+	}
+	if exclude, err = compileAnchored(c.PluginExclude); err != nil {
+		return nil, nil, fmt.Errorf("pipelines.plugin_exclude: %w", err)
+	}
+	return include, exclude, nil
+}
+
+func compileAnchored(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+type PluginsConfig struct {
+	// ManifestFile is a YAML map of plugin names to their expected versions.
+	ManifestFile string `yaml:"manifest_file"`
+}
+
+type WebConfig struct {
+	ListenAddress string `yaml:"listen_address"`
+	TelemetryPath string `yaml:"telemetry_path"`
+}
+
+type ProbeConfig struct {
+	// AuthenticatedTargets are the probe targets sent the TLS client certificate, authentication and headers
+	// of the logstash section, besides the scrape URIs. Other targets are probed without them.
+	AuthenticatedTargets []string `yaml:"authenticated_targets"`
+}
+
+// Authenticated tells whether the probe target is sent the client certificate, authentication and headers.
+func (c *Config) Authenticated(target string) bool {
+	target = strings.TrimSuffix(target, "/")
+	for _, uris := range [][]string{c.Logstash.ScrapeURIs, c.Probe.AuthenticatedTargets} {
+		for _, uri := range uris {
+			if strings.TrimSuffix(uri, "/") == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type LogstashConfig struct {
+	ScrapeURIs []string             `yaml:"scrape_uris"`
+	Timeout    time.Duration        `yaml:"timeout"`
+	TLSConfig  promconfig.TLSConfig `yaml:"tls_config"`
+
+	// At most one of BasicAuth, BearerToken(File) and APIKey(File) may be set.
+	BasicAuth       *promconfig.BasicAuth `yaml:"basic_auth,omitempty"`
+	BearerToken     promconfig.Secret     `yaml:"bearer_token,omitempty"`
+	BearerTokenFile string                `yaml:"bearer_token_file,omitempty"`
+	APIKey          promconfig.Secret     `yaml:"api_key,omitempty"`
+	APIKeyFile      string                `yaml:"api_key_file,omitempty"`
+
+	// Headers are added to every request to logstash. Their values are hidden like other secrets.
+	Headers map[string]promconfig.Secret `yaml:"headers,omitempty"`
+	// HeaderFiles are added like Headers, with the values read from the files on every request.
+	HeaderFiles map[string]string `yaml:"header_files,omitempty"`
+}
+
+// HTTPClientConfig returns the TLS, basic auth and bearer token settings of the client requesting the logstash API.
+func (c *LogstashConfig) HTTPClientConfig() promconfig.HTTPClientConfig {
+	return promconfig.HTTPClientConfig{
+		BasicAuth:       c.BasicAuth,
+		BearerToken:     c.BearerToken,
+		BearerTokenFile: c.BearerTokenFile,
+		TLSConfig:       c.TLSConfig,
+	}
+}
+
+// Default returns the configuration used when no config file is given.
+func Default() *Config {
+	return &Config{
+		Web: WebConfig{
+			ListenAddress: DefaultListenAddress,
+			TelemetryPath: DefaultTelemetryPath,
+		},
+		Logstash: LogstashConfig{
+			ScrapeURIs: []string{DefaultScrapeURI},
+			Timeout:    DefaultTimeout,
+		},
+	}
+}
+
+// Load reads the YAML config file and fills the missing fields with defaults.
+// The config is not validated, as flags may still override it: call Validate once they are applied.
+func Load(filename string) (*Config, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := Default()
+	if err := yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// LoadPluginManifest reads the YAML map of plugin names to their expected versions.
+func LoadPluginManifest(filename string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]string
+	if err := yaml.UnmarshalStrict(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filename, err)
+	}
+	for name, version := range manifest {
+		if name == "" || version == "" {
+			return nil, fmt.Errorf("invalid manifest %s: plugin %q must have a version", filename, name)
+		}
+	}
+	return manifest, nil
+}
+
+// Validate checks the config is usable.
+func (c *Config) Validate() error {
+	if c.Web.ListenAddress == "" {
+		return errors.New("web.listen_address must not be empty")
+	}
+	if c.Web.TelemetryPath == "" || c.Web.TelemetryPath[0] != '/' {
+		return fmt.Errorf("web.telemetry_path %q must start with '/'", c.Web.TelemetryPath)
+	}
+	if c.Web.TelemetryPath == "/" || c.Web.TelemetryPath == ProbePath {
+		return fmt.Errorf("web.telemetry_path %q is reserved", c.Web.TelemetryPath)
+	}
+
+	if len(c.Logstash.ScrapeURIs) == 0 {
+		return errors.New("logstash.scrape_uris must not be empty")
+	}
+	seen := make(map[string]bool, len(c.Logstash.ScrapeURIs))
+	for _, uri := range c.Logstash.ScrapeURIs {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return fmt.Errorf("logstash.scrape_uris: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("logstash.scrape_uris: %q must be like http(s)://host:port", uri)
+		}
+		if seen[uri] {
+			return fmt.Errorf("logstash.scrape_uris: %q is duplicated", uri)
+		}
+		seen[uri] = true
+	}
+	for _, uri := range c.Probe.AuthenticatedTargets {
+		if u, err := url.Parse(uri); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("probe.authenticated_targets: %q must be like http(s)://host:port", uri)
+		}
+	}
+	if c.Logstash.Timeout <= 0 {
+		return fmt.Errorf("logstash.timeout must be positive, got %s", c.Logstash.Timeout)
+	}
+	if tls := c.Logstash.TLSConfig; (tls.CertFile == "") != (tls.KeyFile == "") {
+		return errors.New("logstash.tls_config: cert_file and key_file must be given together")
+	}
+	if err := c.Logstash.validateAuth(); err != nil {
+		return err
+	}
+
+	for name := range c.Collectors {
+		if !isSubCollector(name) {
+			return fmt.Errorf("collectors: unknown collector %q", name)
+		}
+	}
+	for _, pluginType := range c.Pipelines.PluginTypes {
+		if !contains(collector.PluginTypes, pluginType) {
+			return fmt.Errorf("pipelines.plugin_types: unknown plugin type %q, must be one of %s",
+				pluginType, strings.Join(collector.PluginTypes, ", "))
+		}
+	}
+	if _, _, err := c.Pipelines.Regexps(); err != nil {
+		return err
+	}
+	if _, _, err := c.Pipelines.PluginRegexps(); err != nil {
+		return err
+	}
+
+	for name := range c.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("labels: %q is not a valid label name", name)
+		}
+		if name == TargetLabel {
+			return fmt.Errorf("labels: %q is reserved", name)
+		}
+		if contains(collector.LabelNames, name) {
+			return fmt.Errorf("labels: %q is reserved, as it is a label of the exported metrics", name)
+		}
+	}
+	return nil
+}
+
+func isSubCollector(name string) bool {
+	for _, sc := range collector.SubCollectors {
+		if sc.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LogstashConfig) validateAuth() error {
+	methods := 0
+	if c.BasicAuth != nil {
+		methods++
+		if c.BasicAuth.Username == "" {
+			return errors.New("logstash.basic_auth.username must not be empty")
+		}
+		if c.BasicAuth.Password != "" && c.BasicAuth.PasswordFile != "" {
+			return errors.New("logstash.basic_auth: at most one of password and password_file must be configured")
+		}
+	}
+	if c.BearerToken != "" && c.BearerTokenFile != "" {
+		return errors.New("logstash: at most one of bearer_token and bearer_token_file must be configured")
+	}
+	if c.BearerToken != "" || c.BearerTokenFile != "" {
+		methods++
+	}
+	if c.APIKey != "" && c.APIKeyFile != "" {
+		return errors.New("logstash: at most one of api_key and api_key_file must be configured")
+	}
+	if c.APIKey != "" || c.APIKeyFile != "" {
+		methods++
+	}
+	if methods > 1 {
+		return errors.New("logstash: at most one of basic_auth, bearer_token and api_key must be configured")
+	}
+
+	names := make(map[string]bool, len(c.Headers)+len(c.HeaderFiles))
+	checkHeader := func(field, name string) error {
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("logstash.%s: %q is not a valid header name", field, name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if methods > 0 && name == "Authorization" {
+			return fmt.Errorf("logstash.%s: Authorization conflicts with the configured authentication", field)
+		}
+		if names[name] {
+			return fmt.Errorf("logstash.%s: %q is already given", field, name)
+		}
+		names[name] = true
+		return nil
+	}
+	for name := range c.Headers {
+		if err := checkHeader("headers", name); err != nil {
+			return err
+		}
+	}
+	for name, file := range c.HeaderFiles {
+		if err := checkHeader("header_files", name); err != nil {
+			return err
+		}
+		if file == "" {
+			return fmt.Errorf("logstash.header_files: the file of %q must not be empty", name)
+		}
+	}
+	return nil
+}
+
+func keys(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes content to a config file in dir and returns its path.
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	filename := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg, err := Load(writeConfig(t, dir, `
+logstash:
+  scrape_uris: [http://logstash-1:9600, http://logstash-2:9600]
+labels:
+  env: production
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Logstash.ScrapeURIs; len(got) != 2 || got[1] != "http://logstash-2:9600" {
+		t.Errorf("scrape_uris = %v", got)
+	}
+	if cfg.Labels["env"] != "production" {
+		t.Errorf("labels = %v", cfg.Labels)
+	}
+	// The missing fields keep their defaults.
+	if cfg.Web.ListenAddress != DefaultListenAddress || cfg.Logstash.Timeout != DefaultTimeout {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown field", "web:\n  listen: :9649\n", "field listen not found"},
+		{"empty listen address", "web:\n  listen_address: ''\n", "web.listen_address must not be empty"},
+		{"relative telemetry path", "web:\n  telemetry_path: metrics\n", `web.telemetry_path "metrics" must start with '/'`},
+		{"probe telemetry path", "web:\n  telemetry_path: /probe\n", `web.telemetry_path "/probe" is reserved`},
+		{"root telemetry path", "web:\n  telemetry_path: /\n", `web.telemetry_path "/" is reserved`},
+		{"no scrape URI", "logstash:\n  scrape_uris: []\n", "logstash.scrape_uris must not be empty"},
+		{"bad scheme", "logstash:\n  scrape_uris: [ftp://logstash:9600]\n", `"ftp://logstash:9600" must be like http(s)://host:port`},
+		{"duplicated URI", "logstash:\n  scrape_uris: [http://a:9600, http://a:9600]\n", `"http://a:9600" is duplicated`},
+		{"bad authenticated target", "probe:\n  authenticated_targets: [logstash:9600]\n", `probe.authenticated_targets: "logstash:9600" must be like http(s)://host:port`},
+		{"negative timeout", "logstash:\n  timeout: -1s\n", "logstash.timeout must be positive"},
+		{"cert without key", "logstash:\n  tls_config:\n    cert_file: client.crt\n", "cert_file and key_file must be given together"},
+		{"basic auth without username", "logstash:\n  basic_auth:\n    password: secret\n", "logstash.basic_auth.username must not be empty"},
+		{"two auth methods", "logstash:\n  bearer_token: t\n  api_key: k\n", "at most one of basic_auth, bearer_token and api_key"},
+		{"authorization header with auth", "logstash:\n  api_key: k\n  headers:\n    authorization: x\n", "Authorization conflicts"},
+		{"header in both", "logstash:\n  headers:\n    X-Token: a\n  header_files:\n    x-token: /token\n", `logstash.header_files: "X-Token" is already given`},
+		{"empty header file", "logstash:\n  header_files:\n    X-Token: ''\n", `the file of "X-Token" must not be empty`},
+		{"invalid label name", "labels:\n  1env: x\n", `labels: "1env" is not a valid label name`},
+		{"target label", "labels:\n  target: x\n", `labels: "target" is reserved`},
+		{"exported label", "labels:\n  pipeline: x\n", `labels: "pipeline" is reserved`},
+		{"unknown collector", "collectors:\n  jmv: true\n", `collectors: unknown collector "jmv"`},
+		{"unknown plugin type", "pipelines:\n  plugin_types: [outputs]\n", `pipelines.plugin_types: unknown plugin type "outputs"`},
+		{"invalid pipeline regexp", "pipelines:\n  include: '('\n", "pipelines.include: error parsing regexp"},
+		{"invalid plugin regexp", "pipelines:\n  plugin_exclude: '['\n", "pipelines.plugin_exclude: error parsing regexp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, dir, tt.content))
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if err == nil {
+				t.Fatalf("Load() and Validate() succeeded, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticated(t *testing.T) {
+	cfg := Default()
+	cfg.Probe.AuthenticatedTargets = []string{"https://logstash-2:9600/"}
+	tests := map[string]bool{
+		DefaultScrapeURI:          true,
+		DefaultScrapeURI + "/":    true,
+		"https://logstash-2:9600": true,
+		"http://logstash-2:9600":  false,
+		"http://attacker:9600":    false,
+	}
+	for target, want := range tests {
+		if got := cfg.Authenticated(target); got != want {
+			t.Errorf("Authenticated(%q) = %v, want %v", target, got, want)
+		}
+	}
+}
+
+func TestValidateDefault(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Logstash.Timeout != 5*time.Second {
+		t.Errorf("timeout = %s", cfg.Logstash.Timeout)
+	}
+}
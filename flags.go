@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Wing924/logstash-exporter/collector"
+	"github.com/Wing924/logstash-exporter/config"
+
+	promconfig "github.com/prometheus/common/config"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// collectorFlags holds the --collector.<name> flags of the sub-collectors.
+var collectorFlags = map[string]*bool{}
+
+func init() {
+	for _, sc := range collector.SubCollectors {
+		collectorFlags[sc.Name] = kingpin.Flag("collector."+sc.Name, "Enable the "+sc.Name+" collector: "+sc.Help).
+			Default(strconv.FormatBool(sc.Default)).Action(markSet).Bool()
+	}
+}
+
+// setFlags records the flags given on the command line, so that only they override the config file.
+var setFlags = map[string]bool{}
+
+func markSet(ctx *kingpin.ParseContext) error {
+	for _, element := range ctx.Elements {
+		if flag, ok := element.Clause.(*kingpin.FlagClause); ok {
+			setFlags[flag.Model().Name] = true
+		}
+	}
+	return nil
+}
+
+// splitHeader splits the 'Name: value' of a header flag.
+func splitHeader(flag, header string) (name, value string, err error) {
+	i := strings.Index(header, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("--%s %q must be like 'Name: value'", flag, header)
+	}
+	return strings.TrimSpace(header[:i]), strings.TrimSpace(header[i+1:]), nil
+}
+
+// applyFlags overrides cfg with the flags given on the command line.
+func applyFlags(cfg *config.Config) error {
+	if setFlags["web.listen-address"] {
+		cfg.Web.ListenAddress = *listenAddress
+	}
+	if setFlags["web.telemetry-path"] {
+		cfg.Web.TelemetryPath = *metricsPath
+	}
+	if setFlags["logstash.scrape-uri"] {
+		cfg.Logstash.ScrapeURIs = []string{*logstashScrapeURI}
+	}
+	if setFlags["logstash.timeout"] {
+		cfg.Logstash.Timeout = *logstashTimeout
+	}
+
+	tls := &cfg.Logstash.TLSConfig
+	if setFlags["logstash.tls.ca-file"] {
+		tls.CAFile = *tlsCAFile
+	}
+	if setFlags["logstash.tls.cert-file"] {
+		tls.CertFile = *tlsCertFile
+	}
+	if setFlags["logstash.tls.key-file"] {
+		tls.KeyFile = *tlsKeyFile
+	}
+	if setFlags["logstash.tls.server-name"] {
+		tls.ServerName = *tlsServerName
+	}
+	if setFlags["logstash.tls.insecure-skip-verify"] {
+		tls.InsecureSkipVerify = *tlsInsecureSkipVerify
+	}
+
+	if setFlags["logstash.username"] || setFlags["logstash.password-file"] {
+		if cfg.Logstash.BasicAuth == nil {
+			cfg.Logstash.BasicAuth = &promconfig.BasicAuth{}
+		}
+		if setFlags["logstash.username"] {
+			cfg.Logstash.BasicAuth.Username = *username
+		}
+		if setFlags["logstash.password-file"] {
+			cfg.Logstash.BasicAuth.Password = ""
+			cfg.Logstash.BasicAuth.PasswordFile = *passwordFile
+		}
+	}
+	if setFlags["logstash.bearer-token-file"] {
+		cfg.Logstash.BearerToken = ""
+		cfg.Logstash.BearerTokenFile = *bearerTokenFile
+	}
+	if setFlags["logstash.api-key-file"] {
+		cfg.Logstash.APIKey = ""
+		cfg.Logstash.APIKeyFile = *apiKeyFile
+	}
+	if setFlags["logstash.header"] {
+		if cfg.Logstash.Headers == nil {
+			cfg.Logstash.Headers = make(map[string]promconfig.Secret, len(*headers))
+		}
+		for _, header := range *headers {
+			name, value, err := splitHeader("logstash.header", header)
+			if err != nil {
+				return err
+			}
+			cfg.Logstash.Headers[name] = promconfig.Secret(value)
+		}
+	}
+	if setFlags["logstash.header-file"] {
+		if cfg.Logstash.HeaderFiles == nil {
+			cfg.Logstash.HeaderFiles = make(map[string]string, len(*headerFiles))
+		}
+		for _, header := range *headerFiles {
+			name, file, err := splitHeader("logstash.header-file", header)
+			if err != nil {
+				return err
+			}
+			cfg.Logstash.HeaderFiles[name] = file
+		}
+	}
+
+	if setFlags["probe.authenticated-target"] {
+		cfg.Probe.AuthenticatedTargets = *probeAuthenticatedTargets
+	}
+
+	if setFlags["collector.plugins.manifest-file"] {
+		cfg.Plugins.ManifestFile = *pluginManifestFile
+	}
+	if setFlags["collector.pipelines.include"] {
+		cfg.Pipelines.Include = *pipelinesInclude
+	}
+	if setFlags["collector.pipelines.exclude"] {
+		cfg.Pipelines.Exclude = *pipelinesExclude
+	}
+	if setFlags["collector.pipelines.plugin-type"] {
+		cfg.Pipelines.PluginTypes = *pluginTypes
+	}
+	if setFlags["collector.pipelines.plugin-include"] {
+		cfg.Pipelines.PluginInclude = *pluginInclude
+	}
+	if setFlags["collector.pipelines.plugin-exclude"] {
+		cfg.Pipelines.PluginExclude = *pluginExclude
+	}
+
+	for name, enabled := range collectorFlags {
+		if setFlags["collector."+name] {
+			if cfg.Collectors == nil {
+				cfg.Collectors = make(map[string]bool)
+			}
+			cfg.Collectors[name] = *enabled
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Wing924/logstash-exporter/config"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestApplyFlagsOverridesConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yml")
+	content := `
+web:
+  listen_address: ":1111"
+logstash:
+  scrape_uris: [http://logstash:9600]
+  timeout: 10s
+collectors:
+  jvm: true
+`
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	setFlags = map[string]bool{}
+	if _, err := kingpin.CommandLine.Parse([]string{"--web.listen-address=:2222", "--no-collector.jvm"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyFlags(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags given on the command line override the config file.
+	if cfg.Web.ListenAddress != ":2222" {
+		t.Errorf("listen_address = %q, want the flag value", cfg.Web.ListenAddress)
+	}
+	if cfg.Collectors["jvm"] {
+		t.Error("collectors.jvm = true, want the --no-collector.jvm flag value")
+	}
+	// The defaults of the other flags don't.
+	if cfg.Logstash.Timeout != 10*time.Second {
+		t.Errorf("timeout = %s, want the config value", cfg.Logstash.Timeout)
+	}
+	if uris := cfg.Logstash.ScrapeURIs; len(uris) != 1 || uris[0] != "http://logstash:9600" {
+		t.Errorf("scrape_uris = %v, want the config value", uris)
+	}
+}
+
+func TestApplyFlagsCompletesConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "config.yml")
+	content := `
+logstash:
+  tls_config:
+    cert_file: client.crt
+`
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The config file is only valid with the key file given on the command line.
+	setFlags = map[string]bool{}
+	if _, err := kingpin.CommandLine.Parse([]string{"--logstash.tls.key-file=client.key"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyFlags(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want the flag to complete the config", err)
+	}
+}
+
+func TestApplyHeaderFlags(t *testing.T) {
+	setFlags = map[string]bool{}
+	args := []string{"--logstash.header=X-User: exporter", "--logstash.header-file=X-Token: /etc/token"}
+	if _, err := kingpin.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	if err := applyFlags(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Logstash.Headers["X-User"]; got != "exporter" {
+		t.Errorf("headers[X-User] = %q, want the flag value", got)
+	}
+	if got := cfg.Logstash.HeaderFiles["X-Token"]; got != "/etc/token" {
+		t.Errorf("header_files[X-Token] = %q, want the flag value", got)
+	}
+}
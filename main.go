@@ -4,21 +4,46 @@ import (
 	"net/http"
 
 	"github.com/Wing924/logstash-exporter/collector"
+	"github.com/Wing924/logstash-exporter/config"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/version"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+var (
+	configFile        = kingpin.Flag("config.file", "Path to the YAML configuration file. Flags given explicitly override it.").String()
+	listenAddress     = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(config.DefaultListenAddress).Action(markSet).String()
+	metricsPath       = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default(config.DefaultTelemetryPath).Action(markSet).String()
+	logstashScrapeURI = kingpin.Flag("logstash.scrape-uri", "URI on which to scrape logstash.").Default(config.DefaultScrapeURI).Action(markSet).String()
+	logstashTimeout   = kingpin.Flag("logstash.timeout", "Timeout for trying to get stats from logstash.").Default(config.DefaultTimeout.String()).Action(markSet).Duration()
+
+	tlsCAFile             = kingpin.Flag("logstash.tls.ca-file", "CA certificate to verify the logstash API certificate.").Action(markSet).String()
+	tlsCertFile           = kingpin.Flag("logstash.tls.cert-file", "Client certificate file for mutual TLS with logstash.").Action(markSet).String()
+	tlsKeyFile            = kingpin.Flag("logstash.tls.key-file", "Client key file for mutual TLS with logstash.").Action(markSet).String()
+	tlsServerName         = kingpin.Flag("logstash.tls.server-name", "Server name used to verify the logstash API certificate.").Action(markSet).String()
+	tlsInsecureSkipVerify = kingpin.Flag("logstash.tls.insecure-skip-verify", "Skip verification of the logstash API certificate. Insecure.").Action(markSet).Bool()
+
+	username        = kingpin.Flag("logstash.username", "Username for basic authentication to logstash.").Action(markSet).String()
+	passwordFile    = kingpin.Flag("logstash.password-file", "File containing the password for basic authentication to logstash.").Action(markSet).String()
+	bearerTokenFile = kingpin.Flag("logstash.bearer-token-file", "File containing the bearer token sent to logstash.").Action(markSet).String()
+	apiKeyFile      = kingpin.Flag("logstash.api-key-file", "File containing the API key sent to logstash as 'Authorization: ApiKey <key>'.").Action(markSet).String()
+	headers         = kingpin.Flag("logstash.header", "Header added to every request to logstash, as 'Name: value'. Can be repeated. The value is visible in the process arguments, so use --logstash.header-file for secrets.").Action(markSet).Strings()
+	headerFiles     = kingpin.Flag("logstash.header-file", "Header added to every request to logstash, as 'Name: /path/to/file' of the file containing the value. Can be repeated.").Action(markSet).Strings()
+
+	probeAuthenticatedTargets = kingpin.Flag("probe.authenticated-target", "Probe target sent the TLS client certificate, authentication and headers, besides the scrape URI. Can be repeated.").Action(markSet).Strings()
+
+	pluginManifestFile = kingpin.Flag("collector.plugins.manifest-file", "YAML map of plugin names to their expected versions, checked by the plugins collector.").Action(markSet).String()
+	pipelinesInclude   = kingpin.Flag("collector.pipelines.include", "Regular expression matching the whole id of the pipelines to export.").Action(markSet).String()
+	pipelinesExclude   = kingpin.Flag("collector.pipelines.exclude", "Regular expression matching the whole id of the pipelines not to export.").Action(markSet).String()
+	pluginTypes        = kingpin.Flag("collector.pipelines.plugin-type", "Plugin type to export. Can be repeated. All types are exported if not given.").Action(markSet).Enums(collector.PluginTypes...)
+	pluginInclude      = kingpin.Flag("collector.pipelines.plugin-include", "Regular expression matching the whole name or id of the plugins to export.").Action(markSet).String()
+	pluginExclude      = kingpin.Flag("collector.pipelines.plugin-exclude", "Regular expression matching the whole name or id of the plugins not to export.").Action(markSet).String()
+)
+
 func main() {
-	var (
-		listenAddress     = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9649").String()
-		metricsPath       = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
-		logstashScrapeURI = kingpin.Flag("logstash.scrape-uri", "URI on which to scrape logstash.").Default("http://localhost:9600").String()
-		logstashTimeout   = kingpin.Flag("logstash.timeout", "Timeout for trying to get stats from logstash.").Default("5s").Duration()
-	)
 	kingpin.HelpFlag.Short('h')
 	kingpin.Version(version.Print("logstash-exporter"))
 	kingpin.Parse()
@@ -28,24 +53,90 @@ func main() {
 		"build":   version.BuildContext(),
 	}).Info("Starting logstash-exporter")
 
-	exporter, err := collector.NewCollector(*logstashScrapeURI, *logstashTimeout)
+	cfg := config.Default()
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			logrus.WithError(err).Fatal("failed to load config file")
+		}
+	}
+	if err := applyFlags(cfg); err != nil {
+		logrus.WithError(err).Fatal("invalid flag")
+	}
+	if err := cfg.Validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid configuration")
+	}
+
+	client, err := collector.NewHTTPClient(collector.ClientConfig{
+		HTTPClientConfig: cfg.Logstash.HTTPClientConfig(),
+		APIKey:           cfg.Logstash.APIKey,
+		APIKeyFile:       cfg.Logstash.APIKeyFile,
+		Headers:          cfg.Logstash.Headers,
+		HeaderFiles:      cfg.Logstash.HeaderFiles,
+		Timeout:          cfg.Logstash.Timeout,
+	})
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to create HTTP client")
+	}
+	// Probe targets are chosen by the caller, so only the configured ones are sent the secrets.
+	anonymousClient, err := collector.NewHTTPClient(collector.ClientConfig{
+		HTTPClientConfig: promconfig.HTTPClientConfig{
+			TLSConfig: promconfig.TLSConfig{
+				CAFile:             cfg.Logstash.TLSConfig.CAFile,
+				InsecureSkipVerify: cfg.Logstash.TLSConfig.InsecureSkipVerify,
+			},
+		},
+		Timeout: cfg.Logstash.Timeout,
+	})
 	if err != nil {
-		logrus.WithError(err).Fatal("failed to create exporter")
+		logrus.WithError(err).Fatal("failed to create HTTP client")
+	}
+	opts := collector.Options{Client: client, Collectors: cfg.Collectors}
+	// The regexps were checked by cfg.Validate.
+	opts.PipelineInclude, opts.PipelineExclude, _ = cfg.Pipelines.Regexps()
+	opts.PluginInclude, opts.PluginExclude, _ = cfg.Pipelines.PluginRegexps()
+	opts.PluginTypes = cfg.Pipelines.PluginTypes
+	if cfg.Plugins.ManifestFile != "" {
+		if opts.PluginManifest, err = config.LoadPluginManifest(cfg.Plugins.ManifestFile); err != nil {
+			logrus.WithError(err).Fatal("failed to load plugin manifest")
+		}
+	}
+
+	var targets []target
+	for _, uri := range cfg.Logstash.ScrapeURIs {
+		exporter, err := collector.NewCollector(uri, opts)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to create exporter")
+		}
+		labels := prometheus.Labels{}
+		for name, value := range cfg.Labels {
+			labels[name] = value
+		}
+		if len(cfg.Logstash.ScrapeURIs) > 1 {
+			labels[config.TargetLabel] = uri
+		}
+		if err := prometheus.WrapRegistererWith(labels, prometheus.DefaultRegisterer).Register(exporter); err != nil {
+			logrus.WithError(err).WithField("uri", uri).Fatal("failed to register exporter")
+		}
+		targets = append(targets, target{exporter: exporter, labels: labels})
 	}
-	prometheus.MustRegister(exporter)
 	prometheus.MustRegister(version.NewCollector("logstash_exporter"))
 
-	http.Handle(*metricsPath, promhttp.Handler())
+	http.Handle(cfg.Web.TelemetryPath, metricsHandler(targets))
+	anonymousOpts := opts
+	anonymousOpts.Client = anonymousClient
+	http.Handle(config.ProbePath, probeHandler(cfg.Labels, opts, anonymousOpts, cfg.Authenticated))
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte(`<html>
              <head><title>Logstash Collector</title></head>
              <body>
              <h1>Logstash Collector</h1>
-             <p><a href='` + *metricsPath + `'>Metrics</a></p>
+             <p><a href='` + cfg.Web.TelemetryPath + `'>Metrics</a></p>
+             <p><a href='/probe?target=http://localhost:9600'>Probe http://localhost:9600</a></p>
              </body>
              </html>`))
 	})
 
-	logrus.WithField("address", *listenAddress).Info("listening...")
-	logrus.Fatal(http.ListenAndServe(*listenAddress, nil))
+	logrus.WithField("address", cfg.Web.ListenAddress).Info("listening...")
+	logrus.Fatal(http.ListenAndServe(cfg.Web.ListenAddress, nil))
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Wing924/logstash-exporter/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// target is a logstash exporter with the labels added to its metrics.
+type target struct {
+	exporter *collector.Collector
+	labels   prometheus.Labels
+}
+
+// metricsHandler serves the metrics of the default registry. With collect[] parameters, like
+// /metrics?collect[]=jvm&collect[]=pipelines, it only runs the named sub-collectors of each target instead.
+func metricsHandler(targets []target) http.Handler {
+	defaultHandler := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := r.URL.Query()["collect[]"]
+		if len(names) == 0 {
+			defaultHandler.ServeHTTP(w, r)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		for _, t := range targets {
+			if err := registerFiltered(prometheus.WrapRegistererWith(t.labels, registry), t.exporter, names); err != nil {
+				logrus.WithError(err).Warn("invalid collect[] parameter")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// registerFiltered registers the collector running only the named sub-collectors of the exporter.
+func registerFiltered(r prometheus.Registerer, exporter *collector.Collector, names []string) error {
+	filtered, err := exporter.Filtered(names)
+	if err != nil {
+		return err
+	}
+	return r.Register(filtered)
+}
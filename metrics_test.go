@@ -0,0 +1,71 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Wing924/logstash-exporter/collector"
+)
+
+// newLogstashServer serves the recorded /_node/stats response, and 404 for the other endpoints.
+func newLogstashServer(t *testing.T) *httptest.Server {
+	content, err := ioutil.ReadFile("collector/testdata/node_stats.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_node/stats" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+}
+
+func TestCollectParameter(t *testing.T) {
+	logstash := newLogstashServer(t)
+	defer logstash.Close()
+
+	exporter, err := collector.NewCollector(logstash.URL, collector.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlers := map[string]http.Handler{
+		"/metrics": metricsHandler([]target{{exporter: exporter}}),
+		"/probe":   probeHandler(nil, collector.Options{}, collector.Options{}, func(string) bool { return false }),
+	}
+	tests := []struct {
+		name       string
+		collect    []string
+		wantStatus int
+		wantBody   string
+	}{
+		{"disabled collector", []string{"jvm", "hot_threads"}, http.StatusBadRequest, `collector "hot_threads" is disabled`},
+		{"unknown collector", []string{"jmv"}, http.StatusBadRequest, `unknown collector "jmv"`},
+		{"jvm only", []string{"jvm"}, http.StatusOK, "logstash_jvm_threads_count"},
+	}
+	for path, handler := range handlers {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				query := url.Values{"collect[]": tt.collect, "target": {logstash.URL}}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest("GET", path+"?"+query.Encode(), nil))
+
+				body := w.Body.String()
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, body)
+				}
+				if !strings.Contains(body, tt.wantBody) {
+					t.Errorf("body doesn't contain %q: %s", tt.wantBody, body)
+				}
+				if strings.Contains(body, "\nlogstash_pipeline_") {
+					t.Errorf("body contains pipeline series: %s", body)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wing924/logstash-exporter/collector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxProbeTargets caps the number of targets whose counters are kept, as the caller of /probe chooses the target.
+	maxProbeTargets = 1000
+	// probeTargetIdleTimeout drops the counters of the targets not probed for a while, like the pods gone away.
+	probeTargetIdleTimeout = time.Hour
+)
+
+// probeHandler scrapes the logstash given by the target parameter and exposes its metrics.
+// Each request builds a collector for the target on a fresh registry, so all metrics are scoped to the target.
+// Like /metrics, collect[] parameters select the sub-collectors to run.
+// The targets for which authenticated returns false are scraped with anonymousOpts, whose client holds no secrets.
+func probeHandler(labels prometheus.Labels, opts, anonymousOpts collector.Options, authenticated func(target string) bool) http.HandlerFunc {
+	counters := &probeCounters{targets: make(map[string]*probeTarget)}
+	return func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("target")
+		if target == "" {
+			http.Error(w, "'target' parameter must be specified", http.StatusBadRequest)
+			return
+		}
+		target = strings.TrimSuffix(target, "/")
+
+		targetOpts := anonymousOpts
+		if authenticated(target) {
+			targetOpts = opts
+		}
+		targetOpts.Counters = counters.get(target, time.Now())
+		exporter, err := collector.NewCollector(target, targetOpts)
+		if err != nil {
+			logrus.WithError(err).WithField("target", target).Warn("invalid probe target")
+			http.Error(w, fmt.Sprintf("invalid target %q: %s", target, err), http.StatusBadRequest)
+			return
+		}
+
+		registry := prometheus.NewRegistry()
+		registerer := prometheus.WrapRegistererWith(labels, registry)
+		if names := r.URL.Query()["collect[]"]; len(names) > 0 {
+			if err := registerFiltered(registerer, exporter, names); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		} else if err := registerer.Register(exporter); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	}
+}
+
+// probeCounters keeps the scrape counters per probed target, so that logstash_exporter_total_scrapes
+// and logstash_exporter_json_parse_failures keep counting across probes of the same target.
+type probeCounters struct {
+	mutex   sync.Mutex
+	targets map[string]*probeTarget
+}
+
+type probeTarget struct {
+	counters *collector.Counters
+	lastUsed time.Time
+}
+
+func (p *probeCounters) get(target string, now time.Time) *collector.Counters {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if t, ok := p.targets[target]; ok {
+		t.lastUsed = now
+		return t.counters
+	}
+
+	// Drop the idle targets, and the least recently used one if there are still too many.
+	var oldest string
+	for name, t := range p.targets {
+		if now.Sub(t.lastUsed) > probeTargetIdleTimeout {
+			delete(p.targets, name)
+			continue
+		}
+		if oldest == "" || t.lastUsed.Before(p.targets[oldest].lastUsed) {
+			oldest = name
+		}
+	}
+	if len(p.targets) >= maxProbeTargets {
+		delete(p.targets, oldest)
+	}
+
+	t := &probeTarget{counters: collector.NewCounters(), lastUsed: now}
+	p.targets[target] = t
+	return t.counters
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProbeCounters(t *testing.T) {
+	p := &probeCounters{targets: make(map[string]*probeTarget)}
+	now := time.Now()
+
+	first := p.get("http://logstash-1:9600", now)
+	if p.get("http://logstash-1:9600", now.Add(time.Minute)) != first {
+		t.Error("the counters of a target are not kept across probes")
+	}
+
+	// The targets gone away are dropped after the idle timeout.
+	p.get("http://logstash-2:9600", now)
+	p.get("http://logstash-3:9600", now.Add(2*probeTargetIdleTimeout))
+	if _, ok := p.targets["http://logstash-2:9600"]; ok {
+		t.Error("the idle target is kept")
+	}
+	if len(p.targets) != 1 {
+		t.Errorf("%d targets kept, want 1", len(p.targets))
+	}
+
+	// The number of targets is capped, dropping the least recently used one.
+	now = now.Add(3 * probeTargetIdleTimeout)
+	for i := 0; i < maxProbeTargets+10; i++ {
+		p.get(fmt.Sprintf("http://logstash-%d:9600", i), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if len(p.targets) != maxProbeTargets {
+		t.Errorf("%d targets kept, want %d", len(p.targets), maxProbeTargets)
+	}
+	if _, ok := p.targets["http://logstash-0:9600"]; ok {
+		t.Error("the least recently used target is kept")
+	}
+	if _, ok := p.targets[fmt.Sprintf("http://logstash-%d:9600", maxProbeTargets+9)]; !ok {
+		t.Error("the last probed target is dropped")
+	}
+}